@@ -0,0 +1,84 @@
+package libjson
+
+import "sync"
+
+// WithArena makes New, NewReader and Parser allocate arrays and maps from an
+// arena, which is reused for the next document once (*JSON).Release is
+// called. Ordered objects are not allocated from the arena
+func WithArena() Option {
+	return func(c *config) {
+		c.arena = true
+	}
+}
+
+// slabSize is the amount of array elements allocated at once, larger arrays
+// are allocated on their own
+const slabSize = 4096
+
+// arena hands out arrays carved from larger slabs and maps, both are
+// cleared and reused after release
+type arena struct {
+	slabs [][]any // slabs[:used] hold arrays of the current document
+	used  int
+	off   int // first free element in slabs[used-1]
+	maps  []map[string]any
+	free  []map[string]any // cleared maps, ready for reuse
+}
+
+var arenas = sync.Pool{New: func() any { return new(arena) }}
+
+// slice returns an array of length n
+func (a *arena) slice(n int) []any {
+	if n > slabSize/8 {
+		return make([]any, n)
+	}
+	if a.used == 0 || a.off+n > slabSize {
+		if a.used == len(a.slabs) {
+			a.slabs = append(a.slabs, make([]any, slabSize))
+		}
+		a.used++
+		a.off = 0
+	}
+	// the capacity is limited, so appending to the array can not overwrite
+	// the next one
+	s := a.slabs[a.used-1][a.off : a.off+n : a.off+n]
+	a.off += n
+	return s
+}
+
+func (a *arena) object() map[string]any {
+	var m map[string]any
+	if n := len(a.free); n > 0 {
+		m = a.free[n-1]
+		a.free = a.free[:n-1]
+	} else {
+		m = make(map[string]any, 8)
+	}
+	a.maps = append(a.maps, m)
+	return m
+}
+
+// release clears everything handed out and puts a back into the pool
+func (a *arena) release() {
+	for _, s := range a.slabs[:a.used] {
+		clear(s)
+	}
+	a.used, a.off = 0, 0
+	for _, m := range a.maps {
+		clear(m)
+	}
+	a.free = append(a.free, a.maps...)
+	clear(a.maps)
+	a.maps = a.maps[:0]
+	arenas.Put(a)
+}
+
+// Release hands the memory of a document parsed WithArena back for reuse by
+// the next document. Afterwards neither j nor any array, map or value taken
+// from it may be used. Documents parsed without WithArena are only cleared
+func (j *JSON) Release() {
+	if j.arena != nil {
+		j.arena.release()
+	}
+	*j = JSON{}
+}
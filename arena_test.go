@@ -0,0 +1,95 @@
+package libjson
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var parserInputs = []string{
+	`{"a": [1, [2, 3], {"b": [true, null]}], "c": "d"}`,
+	`[[], {}, [[[]]], "x"]`,
+	`[1, 2,`,
+	`"str"`,
+	`[{"a": 1}, {"a": 2}, [3, 4, 5, 6, 7, 8, 9, 10, 11]]`,
+}
+
+func TestParser(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithArena()}, {WithOrderedObjects(), WithArena()}} {
+		p := NewParser(opts...)
+		for _, in := range parserInputs {
+			expected, expectedErr := New([]byte(in), opts...)
+			obj, err := p.Parse([]byte(in))
+			assert.Equal(t, expectedErr, err)
+			if err == nil {
+				assert.Equal(t, expected.obj, obj.obj)
+				obj.Release()
+				assert.Nil(t, obj.obj)
+			}
+
+			obj, err = p.ParseReader(iotest.OneByteReader(strings.NewReader(in)))
+			assert.Equal(t, expectedErr, err)
+			if err == nil {
+				assert.Equal(t, expected.obj, obj.obj)
+			}
+			p.Reset()
+			assert.Nil(t, p.p.l.data)
+		}
+	}
+}
+
+func TestArena(t *testing.T) {
+	p := NewParser(WithArena())
+	obj, err := p.Parse([]byte(`[[1, 2], [3, 4]]`))
+	assert.NoError(t, err)
+	a := obj.obj.([]any)
+	// the capacity of arena arrays is limited, appending reallocates
+	first := append(a[0].([]any), 5.0)
+	assert.Equal(t, []any{1.0, 2.0, 5.0}, first)
+	assert.Equal(t, []any{3.0, 4.0}, a[1])
+
+	// arrays too large for a slab
+	large := "[" + strings.Repeat("1,", slabSize) + "1]"
+	obj, err = p.Parse([]byte(large))
+	assert.NoError(t, err)
+	assert.Len(t, obj.obj, slabSize+1)
+	obj.Release()
+
+	// maps are cleared and reused
+	ar := arenas.Get().(*arena)
+	m := ar.object()
+	m["key"] = 1
+	ar.release()
+	ar = arenas.Get().(*arena)
+	if len(ar.free) > 0 {
+		assert.Empty(t, ar.object())
+	}
+	ar.release()
+}
+
+func TestParserPool(t *testing.T) {
+	parsers := sync.Pool{New: func() any { return NewParser(WithArena()) }}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				p := parsers.Get().(*Parser)
+				obj, err := p.Parse([]byte(parserInputs[0]))
+				p.Reset()
+				parsers.Put(p)
+				if assert.NoError(t, err) {
+					v, err := Get[string](obj, ".c")
+					assert.NoError(t, err)
+					assert.Equal(t, "d", v)
+					obj.Release()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
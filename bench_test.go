@@ -0,0 +1,198 @@
+package libjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// benchRecord is the element test/gen.py writes
+const benchRecord = `	{
+        "key1": "value",
+        "array": [],
+        "obj": {},
+        "atomArray": [11201,1e112,true,false,null,"str"]
+    }`
+
+// benchData returns a top level array of benchRecord elements, about size
+// bytes long, like the files generated by test/gen.py
+func benchData(size int) []byte {
+	var b bytes.Buffer
+	b.WriteString("[\n")
+	for i := range size / len(benchRecord) {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		b.WriteString(benchRecord)
+	}
+	b.WriteString("\n]")
+	return b.Bytes()
+}
+
+// benchParse reports the throughput of parsing data with opts
+func benchParse(b *testing.B, data []byte, opts ...Option) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := New(data, opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkNew(b *testing.B) {
+	data := benchData(1 << 20)
+	b.Run("encoding/json", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for range b.N {
+			var v any
+			if err := json.Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("tree", func(b *testing.B) {
+		benchParse(b, data)
+	})
+}
+
+func BenchmarkParallel(b *testing.B) {
+	data := benchData(8 << 20)
+	b.Run("tree", func(b *testing.B) {
+		benchParse(b, data)
+	})
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("WithParallel(%d)", workers), func(b *testing.B) {
+			benchParse(b, data, WithParallel(workers))
+		})
+	}
+}
+
+func BenchmarkSmall(b *testing.B) {
+	// request sized documents, parsed thousands of times per second
+	data := benchData(1 << 10)
+	b.Run("encoding/json", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for range b.N {
+			var v any
+			if err := json.Unmarshal(data, &v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("tree", func(b *testing.B) {
+		benchParse(b, data)
+	})
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Parser", nil},
+		{"Parser/WithArena", []Option{WithArena()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			ps := NewParser(bench.opts...)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for range b.N {
+				j, err := ps.Parse(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				j.Release()
+				ps.Reset()
+			}
+		})
+	}
+}
+
+// retained returns the heap bytes a document parsed from data keeps alive
+func retained(data []byte, opts ...Option) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	j, err := New(data, opts...)
+	if err != nil {
+		panic(err)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(j)
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+func BenchmarkTape(b *testing.B) {
+	data := benchData(1 << 20)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"tree", nil},
+		{"WithTape", []Option{WithTape()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			size := retained(data, bench.opts...)
+			b.ResetTimer()
+			benchParse(b, data, bench.opts...)
+			b.ReportMetric(float64(size), "retained-B")
+		})
+		b.Run(bench.name+"/Get", func(b *testing.B) {
+			j, err := New(data, bench.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := j.get(".4000.atomArray.5"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// stringEndBytewise is stringEnd without SWAR
+func stringEndBytewise(data []byte, i int) int {
+	for ; i < len(data); i++ {
+		if cc := data[i]; cc == '"' || cc == '\\' || cc < 0x20 {
+			return i
+		}
+	}
+	return i
+}
+
+// skipSpaceBytewise is skipSpace without SWAR
+func skipSpaceBytewise(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+func BenchmarkScan(b *testing.B) {
+	str := append(bytes.Repeat([]byte("lorem ipsum dolor sit amet, "), 4), '"')
+	space := append(bytes.Repeat([]byte("\n        "), 4), '{')
+	for _, bench := range []struct {
+		name string
+		data []byte
+		scan func([]byte, int) int
+	}{
+		{"string/swar", str, stringEnd},
+		{"string/bytewise", str, stringEndBytewise},
+		{"space/swar", space, skipSpace},
+		{"space/bytewise", space, skipSpaceBytewise},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bench.data)))
+			for range b.N {
+				if bench.scan(bench.data, 0) != len(bench.data)-1 {
+					b.Fatal("scan stopped early")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package libjson
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WithCST keeps a concrete syntax tree of the input next to the parsed value,
+// holding every token together with the whitespace and comments preceding
+// it. Set then edits both and (*JSON).Bytes reproduces the input byte for
+// byte, except for the edited values. Keys added by Set copy the indentation
+// of their previous sibling. For duplicate keys, Set edits the first
+// occurrence for DuplicateFirstWins and the last one otherwise. With
+// DuplicateCollect, Set fails for paths through keys occurring more than once
+func WithCST() Option {
+	return func(c *config) {
+		c.cst = true
+	}
+}
+
+// cstNode is a value in the concrete syntax tree. lead holds the trivia
+// preceding the value and tok the value itself for atoms or the opening
+// token for containers. close is only set for containers and holds the
+// closing token and its trivia
+type cstNode struct {
+	lead    []byte
+	tok     []byte
+	entries []cstEntry
+	close   []byte
+}
+
+// cstEntry is an object member or an array element, key and colon are only
+// set for members. comma holds the comma following the entry and its trivia,
+// it is nil for the last entry unless the input has a trailing comma
+type cstEntry struct {
+	name  string
+	key   cstNode
+	colon []byte
+	value *cstNode
+	comma []byte
+}
+
+func (n *cstNode) append(buf []byte) []byte {
+	buf = append(buf, n.lead...)
+	buf = append(buf, n.tok...)
+	for _, e := range n.entries {
+		buf = append(buf, e.key.lead...)
+		buf = append(buf, e.key.tok...)
+		buf = append(buf, e.colon...)
+		buf = e.value.append(buf)
+		buf = append(buf, e.comma...)
+	}
+	return append(buf, n.close...)
+}
+
+// cst is the concrete syntax tree of a whole document
+type cst struct {
+	root *cstNode
+	tail []byte // trivia after the root value
+	cfg  config
+}
+
+// cstParser builds a cst from the tokens of p, the input has to be held in
+// memory completely, since trivia is sliced from it
+type cstParser struct {
+	p    parser
+	end  int    // end of the previous token
+	lead []byte // trivia preceding the current token
+}
+
+func newCST(data []byte, cfg config) (*cst, error) {
+	c := cstParser{p: parser{l: lexer{data: data, cfg: cfg}}}
+	if err := c.advance(); err != nil {
+		return nil, err
+	}
+	root, err := c.value()
+	if err != nil {
+		return nil, err
+	}
+	if c.p.t.Type != t_eof {
+		return nil, c.p.l.errorf(c.p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[c.p.t.Type])
+	}
+	return &cst{root: root, tail: data[c.end:], cfg: cfg}, nil
+}
+
+func (c *cstParser) advance() error {
+	c.end = c.p.l.pos
+	if err := c.p.advance(); err != nil {
+		return err
+	}
+	c.lead = c.p.l.data[c.end:c.p.l.start]
+	return nil
+}
+
+// tok returns the current token without its trivia
+func (c *cstParser) tok() []byte {
+	return c.p.l.data[c.p.l.start:c.p.l.pos]
+}
+
+// segment returns the current token including its trivia
+func (c *cstParser) segment() []byte {
+	return c.p.l.data[c.end:c.p.l.pos]
+}
+
+func (c *cstParser) value() (*cstNode, error) {
+	n := &cstNode{lead: c.lead, tok: c.tok()}
+	var closing t_json
+	switch c.p.t.Type {
+	case t_string, t_number, t_true, t_false, t_null:
+		return n, c.advance()
+	case t_left_curly:
+		closing = t_right_curly
+	case t_left_braket:
+		closing = t_right_braket
+	default:
+		return nil, c.p.unexpectedValue()
+	}
+
+	if err := c.advance(); err != nil {
+		return nil, err
+	}
+	for c.p.t.Type != closing {
+		var e cstEntry
+		if closing == t_right_curly {
+			if c.p.t.Type != t_string && !(c.p.l.cfg.json5 && identKey(c.p.t)) {
+				return nil, c.p.expect(t_string)
+			}
+			e.name = c.p.str()
+			e.key = cstNode{lead: c.lead, tok: c.tok()}
+			if err := c.advance(); err != nil {
+				return nil, err
+			}
+			if c.p.t.Type != t_colon {
+				return nil, c.p.expect(t_colon)
+			}
+			e.colon = c.segment()
+			if err := c.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		if e.value, err = c.value(); err != nil {
+			return nil, err
+		}
+		if c.p.t.Type == t_comma {
+			e.comma = c.segment()
+			if err := c.advance(); err != nil {
+				return nil, err
+			}
+		}
+		n.entries = append(n.entries, e)
+		if e.comma == nil {
+			break
+		} else if c.p.t.Type == closing && !c.p.l.cfg.json5 {
+			return nil, c.p.unexpectedValue()
+		}
+	}
+	if c.p.t.Type != closing {
+		return nil, c.p.expect(closing)
+	}
+	n.close = c.segment()
+	return n, c.advance()
+}
+
+// set locates the value at keys and returns the edit replacing it with raw,
+// the serialization of the new value, or adding a member if the last key is
+// missing in its object. The syntax tree is only changed by the edit, so the
+// parsed tree can be changed in between
+func (c *cst) set(keys []any, raw []byte) (func(), error) {
+	if err := c.collected(keys); err != nil {
+		return nil, err
+	}
+	n := c.root
+	for i, k := range keys {
+		// expanding does not change the bytes of the syntax tree
+		if err := c.expand(n); err != nil {
+			return nil, err
+		}
+		e := n.entry(k, c.cfg.duplicates == DuplicateFirstWins)
+		if e != nil {
+			n = e.value
+			continue
+		}
+		name, ok := k.(string)
+		if !ok || i != len(keys)-1 || len(n.tok) == 0 || n.tok[0] == '[' {
+			return nil, fmt.Errorf("Can not set %v, not found in the syntax tree", k)
+		}
+		return func() { n.add(name, raw) }, nil
+	}
+	return func() { n.tok, n.entries, n.close = raw, nil, nil }, nil
+}
+
+// collected fails if keys pass through a key whose occurrences were
+// collected into an array by DuplicateCollect, the syntax tree holds them
+// separately, so setting them can not be reflected
+func (c *cst) collected(keys []any) error {
+	if c.cfg.duplicates != DuplicateCollect {
+		return nil
+	}
+	n := c.root
+	for _, k := range keys {
+		if err := c.expand(n); err != nil {
+			return err
+		}
+		if name, ok := k.(string); ok && n.close != nil && n.tok[0] == '{' {
+			count := 0
+			for _, e := range n.entries {
+				if e.name == name {
+					count++
+				}
+			}
+			if count > 1 {
+				return fmt.Errorf("Can not set %q, the key occurs %d times in the syntax tree", name, count)
+			}
+		}
+		e := n.entry(k, false)
+		if e == nil {
+			return nil
+		}
+		n = e.value
+	}
+	return nil
+}
+
+// expand converts a container previously replaced by set back into a
+// subtree, so its children can be edited
+func (c *cst) expand(n *cstNode) error {
+	if n.close != nil || len(n.tok) == 0 || (n.tok[0] != '{' && n.tok[0] != '[') {
+		return nil
+	}
+	sub, err := newCST(n.tok, c.cfg)
+	if err != nil {
+		return err
+	}
+	lead := n.lead
+	*n = *sub.root
+	n.lead = lead
+	return nil
+}
+
+// entry returns the entry of n for key, or nil. String keys match members of
+// objects, int keys elements of arrays
+func (n *cstNode) entry(key any, firstWins bool) *cstEntry {
+	if n.close == nil {
+		return nil
+	}
+	switch k := key.(type) {
+	case int:
+		if n.tok[0] == '[' && k < len(n.entries) {
+			return &n.entries[k]
+		}
+	case string:
+		if n.tok[0] != '{' {
+			return nil
+		}
+		var found *cstEntry
+		for i := range n.entries {
+			if n.entries[i].name == k {
+				found = &n.entries[i]
+				if firstWins {
+					break
+				}
+			}
+		}
+		return found
+	}
+	return nil
+}
+
+// add appends the member name with the already serialized value raw to the
+// object n, copying the formatting of the previous member
+func (n *cstNode) add(name string, raw []byte) {
+	key := appendString(nil, name)
+	e := cstEntry{
+		name:  name,
+		key:   cstNode{tok: key},
+		colon: []byte{':'},
+		value: &cstNode{tok: raw},
+	}
+	if len(n.entries) > 0 {
+		last := &n.entries[len(n.entries)-1]
+		e.key.lead = indent(last.key.lead)
+		e.colon = last.colon
+		e.value.lead = indent(last.value.lead)
+		if last.comma == nil {
+			last.comma = []byte{','}
+		} else {
+			// keep the trailing comma
+			e.comma = last.comma
+		}
+	}
+	n.entries = append(n.entries, e)
+}
+
+// indent returns the whitespace at the end of lead, starting at its last
+// line break, so comments are not copied to new members
+func indent(lead []byte) []byte {
+	i := len(lead)
+	for i > 0 && (lead[i-1] == ' ' || lead[i-1] == '\t' || lead[i-1] == '\n' || lead[i-1] == '\r') {
+		i--
+	}
+	ws := lead[i:]
+	if j := bytes.LastIndexByte(ws, '\n'); j != -1 {
+		if j > 0 && ws[j-1] == '\r' {
+			j--
+		}
+		ws = ws[j:]
+	}
+	return ws
+}
+
+func (c *cst) bytes() []byte {
+	return append(c.root.append(nil), c.tail...)
+}
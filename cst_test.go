@@ -0,0 +1,163 @@
+package libjson
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSTRoundTrip(t *testing.T) {
+	input := []string{
+		"null",
+		"  \t\n1.50e+1 \n",
+		"[]",
+		"{ }",
+		`{"a" : [ 1 ,2, {"b":"é\n"} ] , "c":{}}`,
+		"{\r\n\t\"crlf\": true\r\n}\r\n",
+	}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			obj, err := New([]byte(in), WithCST())
+			assert.NoError(t, err)
+			out, err := obj.Bytes()
+			assert.NoError(t, err)
+			assert.Equal(t, in, string(out))
+		})
+	}
+
+	obj, err := New([]byte(json5Example), WithCST(), WithJSON5())
+	assert.NoError(t, err)
+	out, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, json5Example, string(out))
+
+	obj, err = NewReader(strings.NewReader(json5Example), WithCST(), WithJSON5())
+	assert.NoError(t, err)
+	out, err = obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, json5Example, string(out))
+}
+
+const cstConfig = `// service config
+{
+  name: 'api', // the public name
+  port: 0x1F90,
+  hosts: [
+    'a.example', /* primary */
+    'b.example',
+  ],
+  tls: {cert: "c.pem"},
+}
+`
+
+func TestCSTSet(t *testing.T) {
+	tests := []struct {
+		path     string
+		value    any
+		expected string
+	}{
+		{".name", "web", strings.Replace(cstConfig, "'api'", `"web"`, 1)},
+		{".hosts.1", "c.example", strings.Replace(cstConfig, "'b.example'", `"c.example"`, 1)},
+		{".tls.cert", nil, strings.Replace(cstConfig, `"c.pem"`, "null", 1)},
+		{".tls", []any{1.0}, strings.Replace(cstConfig, `{cert: "c.pem"}`, "[1]", 1)},
+		{".tls.key", "k.pem", strings.Replace(cstConfig, `{cert: "c.pem"}`, `{cert: "c.pem","key": "k.pem"}`, 1)},
+		{".debug", true, strings.Replace(cstConfig, "},\n}", "},\n  \"debug\": true,\n}", 1)},
+		{".", 1.0, "// service config\n1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			obj, err := New([]byte(cstConfig), WithCST(), WithJSON5())
+			assert.NoError(t, err)
+			assert.NoError(t, obj.set(test.path, test.value))
+			out, err := obj.Bytes()
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(out))
+
+			// the tree and the syntax tree agree
+			reparsed, err := New(out, WithJSON5())
+			assert.NoError(t, err)
+			assert.Equal(t, obj.obj, reparsed.obj)
+		})
+	}
+}
+
+func TestCSTSetStrict(t *testing.T) {
+	input := "{\n    \"a\": 1,\n    \"b\": {}\n}"
+	obj, err := New([]byte(input), WithCST())
+	assert.NoError(t, err)
+
+	assert.NoError(t, Set(obj, ".b", map[string]any{"x": 1.0}))
+	assert.NoError(t, Set(obj, ".b.y", 2.0))
+	assert.NoError(t, Set(obj, ".c", "new"))
+	out, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n    \"a\": 1,\n    \"b\": {\"x\":1,\"y\":2},\n    \"c\": \"new\"\n}", string(out))
+
+	// unserializable values change neither tree
+	assert.Error(t, Set(obj, ".a", math.NaN()))
+	a, err := Get[float64](obj, ".a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, a)
+	out2, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, out, out2)
+
+	assert.Error(t, Set(obj, ".a.b", 1.0))
+	assert.Error(t, Set(obj, ".z.b", 1.0))
+}
+
+func TestCSTDuplicates(t *testing.T) {
+	input := `{"a": 1, "a": 2}`
+	obj, err := New([]byte(input), WithCST(), WithDuplicateKeys(DuplicateFirstWins))
+	assert.NoError(t, err)
+	assert.NoError(t, Set(obj, ".a", 3.0))
+	out, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": 3, "a": 2}`, string(out))
+
+	obj, err = New([]byte(input), WithCST())
+	assert.NoError(t, err)
+	assert.NoError(t, Set(obj, ".a", 3.0))
+	out, err = obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": 1, "a": 3}`, string(out))
+
+	// collected keys can not be edited, the tree and the bytes would differ
+	input = `{"a": [1], "a": [2], "b": {"c": 1}}`
+	obj, err = New([]byte(input), WithCST(), WithDuplicateKeys(DuplicateCollect))
+	assert.NoError(t, err)
+	assert.Error(t, Set(obj, ".a.0", 9.0))
+	assert.Error(t, Set(obj, ".a", 9.0))
+	assert.Equal(t, map[string]any{"a": []any{[]any{1.0}, []any{2.0}}, "b": map[string]any{"c": 1.0}}, obj.obj)
+	assert.NoError(t, Set(obj, ".b.c", 2.0))
+	out, err = obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": [1], "a": [2], "b": {"c": 2}}`, string(out))
+}
+
+func TestCSTSetFails(t *testing.T) {
+	// replaced values are parsed again under the limits once they are edited
+	obj, err := New([]byte(`{"a": 1}`), WithCST(), WithMaxStringLength(4))
+	assert.NoError(t, err)
+	assert.NoError(t, Set(obj, ".a", map[string]any{"b": "too long"}))
+	out, err := obj.Bytes()
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, Set(obj, ".a.b", "ok"), ErrMaxStringLength)
+	b, err := Get[string](obj, ".a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, "too long", b)
+	out2, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, string(out), string(out2))
+}
+
+func TestBytesWithoutCST(t *testing.T) {
+	obj, err := New([]byte(`{ "b": 1, "a": [ true ] }`))
+	assert.NoError(t, err)
+	out, err := obj.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[true],"b":1}`, string(out))
+}
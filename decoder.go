@@ -0,0 +1,77 @@
+package libjson
+
+import (
+	"io"
+)
+
+// Decoder reads a stream of concatenated JSON values, such as
+// `{"a":1}{"b":2}[3]`, from an io.Reader. Values may be separated by
+// whitespace, but do not have to be, except for numbers following numbers
+type Decoder struct {
+	p       parser
+	primed  bool
+	err     error
+	offset  int
+	decoded bool
+}
+
+// NewDecoder returns a Decoder reading from r through a bounded window, see
+// NewReader. The options apply to every value, WithMaxBytes limits the size
+// of every value including the whitespace preceding it
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return &Decoder{p: parser{l: lexer{r: r, cfg: newConfig(opts)}, hold: true}}
+}
+
+// prime reads the first token of the next value, the parser holds the last
+// token of the previous one
+func (d *Decoder) prime() {
+	if !d.primed {
+		d.primed = true
+		d.p.l.base = d.p.l.offset + d.p.l.pos
+		d.err = d.p.advance()
+	}
+}
+
+// More reports whether there is another value in the stream, it blocks until
+// the first token of that value or the end of the stream was read. Pending
+// errors are reported as another value, so Decode returns them
+func (d *Decoder) More() bool {
+	d.prime()
+	return d.err != nil || d.p.t.Type != t_eof
+}
+
+// Decode returns the next value of the stream, io.EOF once the stream is
+// exhausted. It returns as soon as the last byte of the value was read, for
+// numbers this is the byte following them. Errors are sticky
+func (d *Decoder) Decode() (*JSON, error) {
+	d.prime()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if d.p.t.Type == t_eof {
+		return nil, io.EOF
+	}
+	offset := d.p.l.offset + d.p.l.start
+	obj, err := d.p.expression()
+	if err == nil && d.p.l.cfg.maxBytes > 0 && d.p.l.offset+d.p.l.pos-d.p.l.base > d.p.l.cfg.maxBytes {
+		// the lexer only notices once it needs more input than the limit
+		err = &LimitError{Err: ErrMaxBytes, Limit: d.p.l.cfg.maxBytes, Offset: d.p.l.base + d.p.l.cfg.maxBytes}
+	}
+	if err != nil {
+		d.err = err
+		return nil, err
+	}
+	d.primed = false
+	d.offset = offset
+	d.decoded = true
+	return &JSON{obj: obj}, nil
+}
+
+// Offset returns the byte offset of the first byte of the value last
+// returned by Decode in the stream, -1 before the first value
+func (d *Decoder) Offset() int {
+	if !d.decoded {
+		return -1
+	}
+	return d.offset
+}
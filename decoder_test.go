@@ -0,0 +1,108 @@
+package libjson
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder(t *testing.T) {
+	input := `{"a":1}{"b":2}[3]"str"true null 1 2.5` + "\n\t" + `[]`
+	wanted := []any{
+		map[string]any{"a": 1.0},
+		map[string]any{"b": 2.0},
+		[]any{3.0},
+		"str",
+		true,
+		nil,
+		1.0,
+		2.5,
+		[]any{},
+	}
+	offsets := []int{0, 7, 14, 17, 22, 27, 32, 34, 39}
+
+	for _, r := range []io.Reader{strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input))} {
+		d := NewDecoder(r)
+		assert.Equal(t, -1, d.Offset())
+		values := []any{}
+		positions := []int{}
+		for d.More() {
+			j, err := d.Decode()
+			assert.NoError(t, err)
+			values = append(values, j.obj)
+			positions = append(positions, d.Offset())
+		}
+		assert.Equal(t, wanted, values)
+		assert.Equal(t, offsets, positions)
+
+		_, err := d.Decode()
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+func TestDecoderEmpty(t *testing.T) {
+	d := NewDecoder(strings.NewReader(" \n "))
+	assert.False(t, d.More())
+	_, err := d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderError(t *testing.T) {
+	d := NewDecoder(strings.NewReader("{}\n[1,]\n{}"))
+	_, err := d.Decode()
+	assert.NoError(t, err)
+	assert.True(t, d.More())
+	_, err = d.Decode()
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Equal(t, 2, serr.Line)
+		assert.Equal(t, 6, serr.Offset)
+	}
+	// errors are sticky
+	assert.True(t, d.More())
+	_, again := d.Decode()
+	assert.Equal(t, err, again)
+
+	d = NewDecoder(strings.NewReader("#"))
+	assert.True(t, d.More())
+	_, err = d.Decode()
+	assert.Error(t, err)
+}
+
+func TestDecoderNoLookahead(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	d := NewDecoder(r)
+	for _, in := range []string{`{"a":1}`, `[1, [2]]`, `"str"`, ` {}`, `true`, `12 `} {
+		go w.Write([]byte(in))
+		done := make(chan error)
+		go func() {
+			_, err := d.Decode()
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			assert.NoError(t, err, in)
+		case <-time.After(time.Second):
+			t.Fatalf("Decode of %q blocked waiting for more input", in)
+		}
+	}
+}
+
+func TestDecoderMaxBytes(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[1, 2] "abc" 12345 {"a": 1}`), WithMaxBytes(7))
+	for _, wanted := range []any{[]any{1.0, 2.0}, "abc", 12345.0} {
+		j, err := d.Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, wanted, j.obj)
+	}
+	_, err := d.Decode()
+	var lerr *LimitError
+	if assert.ErrorAs(t, err, &lerr) {
+		assert.Equal(t, 18+7, lerr.Offset)
+	}
+}
@@ -0,0 +1,90 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is returned for input not conforming to the JSON grammar, it
+// locates the offending byte in the input. Use errors.As to access it:
+//
+//	var serr *libjson.SyntaxError
+//	if errors.As(err, &serr) {
+//		fmt.Println(serr.Line, serr.Column)
+//		fmt.Println(serr.Snippet)
+//	}
+type SyntaxError struct {
+	Msg     string
+	Offset  int    // byte offset into the input
+	Line    int    // 1-based line number
+	Column  int    // 1-based column, counted in bytes
+	Snippet string // excerpt of the line containing Offset, with a caret below the column
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (line %d, column %d)", e.Msg, e.Line, e.Column)
+}
+
+// snippetWidth is the maximum amount of bytes shown on both sides of the
+// offending byte in SyntaxError.Snippet
+const snippetWidth = 32
+
+// quoteExcerpt quotes b for error messages, cutting it to snippetWidth bytes
+func quoteExcerpt(b []byte) string {
+	if len(b) > snippetWidth {
+		return strconv.Quote(string(b[:snippetWidth])) + "..."
+	}
+	return strconv.Quote(string(b))
+}
+
+// newSyntaxError computes line, column and snippet for offset in data, this
+// is only done for errors to keep the hot path free of line bookkeeping
+func newSyntaxError(data []byte, offset int, msg string) *SyntaxError {
+	offset = min(max(offset, 0), len(data))
+	lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+	lineEnd := len(data)
+	if i := bytes.IndexByte(data[offset:], '\n'); i != -1 {
+		lineEnd = offset + i
+	}
+
+	from := max(lineStart, offset-snippetWidth)
+	to := min(lineEnd, offset+snippetWidth)
+	excerpt := strings.TrimRight(string(data[from:to]), "\r")
+
+	return &SyntaxError{
+		Msg:     msg,
+		Offset:  offset,
+		Line:    bytes.Count(data[:lineStart], []byte{'\n'}) + 1,
+		Column:  offset - lineStart + 1,
+		Snippet: excerpt + "\n" + strings.Repeat(" ", offset-from) + "^",
+	}
+}
+
+// Sentinel errors wrapped by LimitError, test for them via errors.Is
+var (
+	ErrMaxDepth         = errors.New("Maximum nesting depth exceeded")
+	ErrMaxBytes         = errors.New("Maximum input size exceeded")
+	ErrMaxStringLength  = errors.New("Maximum string length exceeded")
+	ErrMaxObjectMembers = errors.New("Maximum amount of object members exceeded")
+	ErrMaxArrayLength   = errors.New("Maximum array length exceeded")
+)
+
+// LimitError is returned if the input exceeds one of the limits configured
+// via WithMaxDepth, WithMaxBytes, WithMaxStringLength, WithMaxObjectMembers
+// or WithMaxArrayLength, Err is the matching ErrMax* sentinel
+type LimitError struct {
+	Err    error
+	Limit  int // the configured limit
+	Offset int // byte offset into the input the limit was exceeded at
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s, limit is %d (offset %d)", e.Err, e.Limit, e.Offset)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,63 @@
+package libjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	input := []struct {
+		inp    string
+		offset int
+		line   int
+		column int
+	}{
+		{"", 0, 1, 1},
+		{"{", 1, 1, 2},
+		{"[1,]", 3, 1, 4},
+		{"{\n  \"a\": 1,\n  \"b\" 2\n}", 18, 3, 7},
+		{"[\n\ttrue,\n\tfalsy\n]", 10, 3, 2},
+		{`["abc`, 1, 1, 2},
+		{`"\q"`, 1, 1, 2},
+		{"[01]", 2, 1, 3},
+		{"{}\r\n{}", 4, 2, 1},
+		{"#", 0, 1, 1},
+	}
+	for _, i := range input {
+		t.Run(i.inp, func(t *testing.T) {
+			_, err := New([]byte(i.inp))
+			var serr *SyntaxError
+			if assert.True(t, errors.As(err, &serr), "%T", err) {
+				assert.Equal(t, i.offset, serr.Offset)
+				assert.Equal(t, i.line, serr.Line)
+				assert.Equal(t, i.column, serr.Column)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorReader(t *testing.T) {
+	_, err := NewReader(strings.NewReader(`{"key": tru}`))
+	var serr *SyntaxError
+	assert.ErrorAs(t, err, &serr)
+	assert.Equal(t, 8, serr.Offset)
+}
+
+func TestSyntaxErrorSnippet(t *testing.T) {
+	_, err := New([]byte("{\n  \"key\": \"value\",\n  \"other\" 12\n}"))
+	var serr *SyntaxError
+	assert.ErrorAs(t, err, &serr)
+	assert.Equal(t, "  \"other\" 12\n          ^", serr.Snippet)
+	assert.Equal(t, `Unexpected "number" at this position, expected ":" (line 3, column 11)`, serr.Error())
+
+	long := `[` + strings.Repeat(`"abcdefgh",`, 20) + `]`
+	_, err = New([]byte(long))
+	assert.ErrorAs(t, err, &serr)
+	lines := strings.Split(serr.Snippet, "\n")
+	assert.Len(t, lines, 2)
+	assert.LessOrEqual(t, len(lines[0]), 2*snippetWidth)
+	assert.Equal(t, byte(']'), lines[0][len(lines[1])-1])
+}
@@ -4,24 +4,147 @@ import (
 	"io"
 )
 
-func NewReader(r io.Reader) (*JSON, error) {
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
+// NewReader parses the JSON value read from r, the input is lexed through a
+// bounded window and never fully held in memory
+func NewReader(r io.Reader, opts ...Option) (*JSON, error) {
+	var p parser
+	return p.documentReader(r, newConfig(opts))
+}
+
+func New(data []byte, opts ...Option) (*JSON, error) {
+	return newJSON(data, newConfig(opts))
+}
+
+func newJSON(data []byte, cfg config) (*JSON, error) {
+	var p parser
+	return p.document(data, cfg)
+}
+
+// Parser parses documents with the same options, reusing its buffers
+// between calls. It is not safe for concurrent use, but can be kept in a
+// sync.Pool:
+//
+//	var parsers = sync.Pool{New: func() any { return libjson.NewParser() }}
+//
+//	p := parsers.Get().(*libjson.Parser)
+//	obj, err := p.Parse(data)
+//	p.Reset()
+//	parsers.Put(p)
+type Parser struct {
+	p   parser
+	cfg config
+}
+
+// NewParser returns a Parser applying opts to every document
+func NewParser(opts ...Option) *Parser {
+	return &Parser{cfg: newConfig(opts)}
+}
+
+// Parse parses data, see New
+func (ps *Parser) Parse(data []byte) (*JSON, error) {
+	return ps.p.document(data, ps.cfg)
+}
+
+// ParseReader parses the JSON value read from r, see NewReader. The window
+// the input is read into is reused for the next call
+func (ps *Parser) ParseReader(r io.Reader) (*JSON, error) {
+	return ps.p.documentReader(r, ps.cfg)
+}
+
+// Reset drops all references to the previous input, so a pooled Parser does
+// not keep it alive. Buffers are kept
+func (ps *Parser) Reset() {
+	ps.p = parser{stack: ps.p.stack[:0], window: ps.p.window[:0]}
+}
+
+// document parses data as a whole, buffers of p are reused
+func (p *parser) document(data []byte, cfg config) (*JSON, error) {
+	if cfg.maxBytes > 0 && len(data) > cfg.maxBytes {
+		return nil, &LimitError{Err: ErrMaxBytes, Limit: cfg.maxBytes, Offset: cfg.maxBytes}
 	}
-	p := parser{l: lexer{data: data}}
-	obj, err := p.parse()
-	if err != nil {
-		return nil, err
+	// the tape holds copies of all strings
+	tape := cfg.tape && cfg.duplicates != DuplicateCollect
+	if !tape {
+		data = cfg.own(data)
 	}
-	return &JSON{obj}, nil
+	p.reset(lexer{data: data, cfg: cfg})
+	if tape {
+		j, err := p.tapeDocument()
+		if err != nil {
+			return nil, p.abort(err)
+		}
+		if cfg.cst {
+			if j.cst, err = newCST(cfg.own(data), cfg); err != nil {
+				j.Release()
+				return nil, err
+			}
+		}
+		return j, nil
+	}
+	if cfg.lazy {
+		j, err := p.lazyDocument(data)
+		if err != nil {
+			return nil, p.abort(err)
+		}
+		j.arena, p.arena = p.arena, nil
+		if cfg.cst {
+			if j.cst, err = newCST(data, cfg); err != nil {
+				j.Release()
+				return nil, err
+			}
+		}
+		return j, nil
+	}
+	obj, ok := parseParallel(data, cfg)
+	if !ok {
+		var err error
+		if obj, err = p.parse(); err != nil {
+			return nil, p.abort(err)
+		}
+	}
+	j := &JSON{obj: obj, arena: p.arena}
+	p.arena = nil
+	var err error
+	if cfg.cst {
+		if j.cst, err = newCST(data, cfg); err != nil {
+			j.Release()
+			return nil, err
+		}
+	}
+	return j, nil
 }
 
-func New(data []byte) (*JSON, error) {
-	p := parser{l: lexer{data: data}}
+// documentReader parses the value read from r, the window of p is reused
+func (p *parser) documentReader(r io.Reader, cfg config) (*JSON, error) {
+	if cfg.cst || cfg.lazy && !cfg.tape {
+		// trivia and lazy values are sliced from the input, so it has to be
+		// held completely
+		if cfg.maxBytes > 0 {
+			r = io.LimitReader(r, int64(cfg.maxBytes)+1)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		// data is not visible to the caller, copying it is not necessary
+		cfg.zeroCopy = true
+		return p.document(data, cfg)
+	}
+	p.reset(lexer{r: r, cfg: cfg, data: p.window[:0]})
+	if cfg.tape && cfg.duplicates != DuplicateCollect {
+		j, err := p.tapeDocument()
+		p.window = p.l.data[:0]
+		if err != nil {
+			return nil, p.abort(err)
+		}
+		return j, nil
+	}
 	obj, err := p.parse()
+	p.window = p.l.data[:0]
 	if err != nil {
-		return nil, err
+		return nil, p.abort(err)
 	}
-	return &JSON{obj}, nil
+	j := &JSON{obj: obj, arena: p.arena}
+	p.arena = nil
+	return j, nil
 }
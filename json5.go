@@ -0,0 +1,453 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithJSON5 enables parsing of JSON5 (https://spec.json5.org), a superset of
+// JSON allowing comments, trailing commas, single quoted strings, unquoted
+// identifier keys, hexadecimal numbers, leading and trailing decimal points,
+// leading '+', Infinity and NaN. Serialization always produces strict JSON,
+// Infinity and NaN can not be serialized
+func WithJSON5() Option {
+	return func(c *config) {
+		c.json5 = true
+	}
+}
+
+// trivia5 skips the JSON5 whitespace and comments starting at cc, which was
+// already consumed, and returns the first byte of the next token. Returns
+// io.EOF if the input ends before another token
+func (l *lexer) trivia5(cc byte) (byte, error) {
+	for {
+		switch {
+		case cc == ' ' || cc == '\n' || cc == '\t' || cc == '\r' || cc == '\v' || cc == '\f':
+		case cc == '/':
+			if !l.ensure(1) {
+				return cc, nil
+			}
+			if l.data[l.pos] == '/' {
+				for cc != '\n' {
+					l.start = l.pos
+					var err error
+					if cc, err = l.advance(); err != nil {
+						return 0, io.EOF
+					}
+				}
+			} else if l.data[l.pos] == '*' {
+				l.pos++
+				for !(cc == '*' && l.ensure(1) && l.data[l.pos] == '/') {
+					l.start = l.pos
+					var err error
+					if cc, err = l.advance(); err != nil {
+						return 0, l.errorf(l.pos, "Unterminated block comment")
+					}
+				}
+				l.pos++
+			} else {
+				return cc, nil
+			}
+		case cc >= utf8.RuneSelf:
+			l.ensure(utf8.UTFMax - 1)
+			r, size := utf8.DecodeRune(l.data[l.pos-1:])
+			if r != '\uFEFF' && r != '\u2028' && r != '\u2029' && !unicode.Is(unicode.Zs, r) {
+				return cc, nil
+			}
+			l.pos += size - 1
+		default:
+			return cc, nil
+		}
+
+		l.start = l.pos
+		var err error
+		if cc, err = l.advance(); err != nil {
+			return 0, io.EOF
+		}
+	}
+}
+
+// token5 lexes the tokens JSON5 adds or lexes differently, cc is the already
+// consumed first byte of the token. Reports false if cc is left to the strict
+// lexer
+func (l *lexer) token5(cc byte) (token, bool, error) {
+	switch {
+	case cc == '"' || cc == '\'':
+		t, err := l.string5(cc)
+		return t, true, err
+	case cc == '+' || cc == '-' || cc == '.' || (cc >= '0' && cc <= '9'):
+		t, err := l.number5()
+		return t, true, err
+	case isIdentStart(cc):
+		t, err := l.identifier()
+		return t, true, err
+	}
+	return empty, false, nil
+}
+
+// string5 lexes a string delimited by quote, supporting the additional
+// escapes of JSON5: \', \v, \0, \xXX, line continuations and every other
+// character escaping itself
+func (l *lexer) string5(quote byte) (token, error) {
+	var buf []byte // only used once an escape was found
+	for {
+		cc, err := l.advance()
+		if err != nil {
+			return empty, l.errorf(l.start, "Unterminated string detected")
+		}
+		switch {
+		case cc == quote:
+			if buf == nil {
+				return token{Type: t_string, Val: l.data[l.start+1 : l.pos-1]}, nil
+			}
+			return token{Type: t_string, Val: buf}, nil
+		case cc == '\n' || cc == '\r':
+			return empty, l.errorf(l.pos-1, "Unescaped line break in string")
+		case cc != '\\':
+			n := l.pos - l.start - 1
+			if buf != nil {
+				buf = append(buf, cc)
+				n = len(buf)
+			}
+			if err := l.stringLimit(n); err != nil {
+				return empty, err
+			}
+			continue
+		}
+
+		if buf == nil {
+			buf = append(make([]byte, 0, l.pos-l.start+16), l.data[l.start+1:l.pos-1]...)
+		}
+		cc, err = l.advance()
+		if err != nil {
+			return empty, l.errorf(l.start, "Unterminated string detected")
+		}
+		switch cc {
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'v':
+			buf = append(buf, '\v')
+		case '0':
+			if l.digit() {
+				return empty, l.errorf(l.pos-2, "Octal escape sequences are not allowed")
+			}
+			buf = append(buf, 0)
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			return empty, l.errorf(l.pos-2, "Invalid escape sequence '\\%c' in string", cc)
+		case 'x', 'u':
+			var r rune
+			if cc == 'x' {
+				r, err = l.hex(2)
+			} else {
+				r, err = l.unicodeEscape()
+			}
+			if err != nil {
+				return empty, err
+			}
+			buf = utf8.AppendRune(buf, r)
+		case '\n':
+			// line continuation
+		case '\r':
+			if l.ensure(1) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+		default:
+			// U+2028 and U+2029 are line continuations too, every other
+			// character escapes itself, the remaining bytes of multi byte
+			// characters are appended by the loop
+			if cc == 0xE2 && l.ensure(2) && l.data[l.pos] == 0x80 && (l.data[l.pos+1] == 0xA8 || l.data[l.pos+1] == 0xA9) {
+				l.pos += 2
+			} else {
+				buf = append(buf, cc)
+			}
+		}
+		if err := l.stringLimit(len(buf)); err != nil {
+			return empty, err
+		}
+	}
+}
+
+// number5 lexes a JSON5 number, the first byte was already consumed
+func (l *lexer) number5() (token, error) {
+	l.pos = l.start
+	if cc := l.data[l.pos]; cc == '+' || cc == '-' {
+		l.pos++
+	}
+
+	if l.word("Infinity") || l.word("NaN") {
+		return l.numberEnd5()
+	}
+
+	if l.ensure(2) && l.data[l.pos] == '0' && (l.data[l.pos+1] == 'x' || l.data[l.pos+1] == 'X') {
+		l.pos += 2
+		start := l.pos
+		for l.ensure(1) && isHexDigit(l.data[l.pos]) {
+			l.pos++
+		}
+		if l.pos == start {
+			return empty, l.numberError("expected hex digit")
+		}
+		return l.numberEnd5()
+	}
+
+	integer := l.digit()
+	if integer {
+		if l.data[l.pos] == '0' {
+			l.pos++
+			if l.digit() {
+				return empty, l.numberError("leading zeros are not allowed")
+			}
+		} else {
+			l.digits()
+		}
+	}
+
+	if l.ensure(1) && l.data[l.pos] == '.' {
+		l.pos++
+		if !integer && !l.digit() {
+			return empty, l.numberError("expected digit after decimal point")
+		}
+		l.digits()
+	} else if !integer {
+		return empty, l.numberError("expected digit")
+	}
+
+	if l.ensure(1) && (l.data[l.pos] == 'e' || l.data[l.pos] == 'E') {
+		l.pos++
+		if l.ensure(1) && (l.data[l.pos] == '+' || l.data[l.pos] == '-') {
+			l.pos++
+		}
+		if !l.digit() {
+			return empty, l.numberError("expected digit in exponent")
+		}
+		l.digits()
+	}
+
+	return l.numberEnd5()
+}
+
+// numberEnd5 rejects numbers directly followed by number or identifier
+// characters, such as 1.2.3 or 0x1G
+func (l *lexer) numberEnd5() (token, error) {
+	if l.ensure(1) {
+		cc := l.data[l.pos]
+		if cc == '+' || cc == '-' || cc == '.' || (cc >= '0' && cc <= '9') || (cc < utf8.RuneSelf && isIdentStart(cc)) {
+			return empty, l.numberError(fmt.Sprintf("unexpected %q", cc))
+		}
+	}
+	return token{Type: t_number, Val: l.data[l.start:l.pos]}, nil
+}
+
+// word consumes w if the input at l.pos starts with it
+func (l *lexer) word(w string) bool {
+	if !l.ensure(len(w)) || string(l.data[l.pos:l.pos+len(w)]) != w {
+		return false
+	}
+	l.pos += len(w)
+	return true
+}
+
+// identifier lexes an ECMAScript IdentifierName, the first byte was already
+// consumed. true, false, null, Infinity and NaN produce their respective
+// tokens, everything else a t_ident
+func (l *lexer) identifier() (token, error) {
+	l.pos = l.start
+	var buf []byte // only used once an escape was found
+	for first := true; l.ensure(1); first = false {
+		// keywords are no strings, so they may exceed the limit, which is
+		// enforced exactly once the identifier ends
+		n := l.pos - l.start
+		if buf != nil {
+			n = len(buf)
+		}
+		if n > len("Infinity") {
+			if err := l.stringLimit(n); err != nil {
+				return empty, err
+			}
+		}
+		cc := l.data[l.pos]
+		if cc == '\\' {
+			if !l.ensure(2) || l.data[l.pos+1] != 'u' {
+				return empty, l.errorf(l.pos, "Invalid escape sequence in identifier")
+			}
+			if buf == nil {
+				buf = append([]byte{}, l.data[l.start:l.pos]...)
+			}
+			l.pos += 2
+			r, err := l.hex(4)
+			if err != nil {
+				return empty, err
+			}
+			if !isIdentRune(r, first) {
+				return empty, l.errorf(l.pos-6, "Invalid character %q in identifier", r)
+			}
+			buf = utf8.AppendRune(buf, r)
+			continue
+		}
+
+		r, size := rune(cc), 1
+		if cc >= utf8.RuneSelf {
+			l.ensure(utf8.UTFMax)
+			r, size = utf8.DecodeRune(l.data[l.pos:])
+		}
+		if !isIdentRune(r, first) {
+			break
+		}
+		if buf != nil {
+			buf = append(buf, l.data[l.pos:l.pos+size]...)
+		}
+		l.pos += size
+	}
+
+	val := buf
+	if val == nil {
+		val = l.data[l.start:l.pos]
+	}
+	if len(val) == 0 {
+		l.pos = l.start + 1
+		return empty, l.errorf(l.start, "Unexpected character %q at this position", l.data[l.start])
+	}
+	// keywords keep their spelling, they are valid object keys
+	switch string(val) {
+	case "true":
+		return token{Type: t_true, Val: val}, nil
+	case "false":
+		return token{Type: t_false, Val: val}, nil
+	case "null":
+		return token{Type: t_null, Val: val}, nil
+	case "Infinity", "NaN":
+		return token{Type: t_number, Val: val}, nil
+	}
+	if err := l.stringLimit(len(val)); err != nil {
+		return empty, err
+	}
+	return token{Type: t_ident, Val: val}, nil
+}
+
+// identKey reports whether t is an unquoted object key, this includes the
+// keywords lexed by identifier
+func identKey(t token) bool {
+	switch t.Type {
+	case t_ident, t_true, t_false, t_null:
+		return len(t.Val) > 0
+	case t_number:
+		s := string(t.Val)
+		return s == "Infinity" || s == "NaN"
+	}
+	return false
+}
+
+// isIdentStart reports whether cc can start an identifier, non ascii bytes
+// are checked by identifier
+func isIdentStart(cc byte) bool {
+	return (cc >= 'a' && cc <= 'z') || (cc >= 'A' && cc <= 'Z') || cc == '_' || cc == '$' || cc == '\\' || cc >= utf8.RuneSelf
+}
+
+func isIdentRune(r rune, first bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) {
+		return true
+	}
+	return !first && (unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D')
+}
+
+func isHexDigit(cc byte) bool {
+	return (cc >= '0' && cc <= '9') || (cc >= 'a' && cc <= 'f') || (cc >= 'A' && cc <= 'F')
+}
+
+// isHex reports whether the lexed number s is a JSON5 hexadecimal integer
+func isHex(s string) bool {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return len(s) > 1 && (s[1] == 'x' || s[1] == 'X')
+}
+
+// strictNumber reports whether the lexed number s conforms to RFC 8259, this
+// does not hold for some JSON5 numbers
+func strictNumber(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	if s[0] == '-' {
+		s = s[1:]
+	}
+	if len(s) == 0 || s[0] < '0' || s[0] > '9' || isHex(s) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '.' && (i+1 == len(s) || s[i+1] < '0' || s[i+1] > '9') {
+			return false
+		}
+	}
+	last := s[len(s)-1]
+	return last >= '0' && last <= '9'
+}
+
+// appendNumber5 serializes the JSON5 only spellings of numbers as strict
+// JSON
+func appendNumber5(buf []byte, n Number) ([]byte, error) {
+	s := string(n)
+	if isHex(s) {
+		i, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, errors.New("Can not serialize number " + s)
+		}
+		return i.Append(buf, 10), nil
+	}
+	// the digits are kept as is, only signs and dots are fixed up, so this
+	// takes linear time for any exponent
+	digits := strings.TrimLeft(s, "+-")
+	if len(digits) == 0 || digits[0] != '.' && (digits[0] < '0' || digits[0] > '9') {
+		return nil, errors.New("Can not serialize number " + s)
+	}
+	if s[0] == '-' {
+		buf = append(buf, '-')
+	}
+	if digits[0] == '.' {
+		buf = append(buf, '0')
+	}
+	for i := 0; i < len(digits); i++ {
+		buf = append(buf, digits[i])
+		if digits[i] == '.' && (i+1 == len(digits) || digits[i+1] < '0' || digits[i+1] > '9') {
+			buf = append(buf, '0')
+		}
+	}
+	return buf, nil
+}
+
+// hexNumber converts a JSON5 hexadecimal integer according to
+// p.l.cfg.numbers
+func (p *parser) hexNumber(raw string) (any, error) {
+	if p.l.cfg.numbers == NumberText {
+		return Number(p.str()), nil
+	}
+	i, ok := new(big.Int).SetString(raw, 0)
+	if !ok {
+		return nil, p.l.errorf(p.l.start, "Invalid hexadecimal number %q", raw)
+	}
+	switch p.l.cfg.numbers {
+	case NumberBig:
+		return i, nil
+	case NumberInteger:
+		if i.IsInt64() {
+			return i.Int64(), nil
+		} else if i.IsUint64() {
+			return i.Uint64(), nil
+		}
+	}
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f, nil
+}
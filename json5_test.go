@@ -0,0 +1,163 @@
+package libjson
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the example from https://json5.org
+const json5Example = `// comments
+{
+  unquoted: 'and you can quote me on that',
+  singleQuotes: 'I can use "double quotes" here',
+  lineBreaks: "Look, Mom! \
+No \\n's!",
+  hexadecimal: 0xdecaf,
+  leadingDecimalPoint: .8675309, andTrailing: 8675309.,
+  positiveSign: +1,
+  trailingComma: 'in objects', andIn: ['arrays',],
+  "backwardsCompatible": "with JSON",
+}
+`
+
+func TestJSON5Example(t *testing.T) {
+	wanted := map[string]any{
+		"unquoted":            "and you can quote me on that",
+		"singleQuotes":        `I can use "double quotes" here`,
+		"lineBreaks":          `Look, Mom! No \n's!`,
+		"hexadecimal":         912559.0,
+		"leadingDecimalPoint": 0.8675309,
+		"andTrailing":         8675309.0,
+		"positiveSign":        1.0,
+		"trailingComma":       "in objects",
+		"andIn":               []any{"arrays"},
+		"backwardsCompatible": "with JSON",
+	}
+	obj, err := New([]byte(json5Example), WithJSON5())
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, obj.obj)
+
+	obj, err = NewReader(iotest.OneByteReader(strings.NewReader(json5Example)), WithJSON5())
+	assert.NoError(t, err)
+	assert.Equal(t, wanted, obj.obj)
+
+	_, err = New([]byte(json5Example))
+	assert.Error(t, err)
+}
+
+func TestJSON5Values(t *testing.T) {
+	input := []struct {
+		inp      string
+		expected any
+	}{
+		{"/* block */ 1 // line", 1.0},
+		{"/**/1/***/", 1.0},
+		{"\ufeff \v\f\u00a0\u2028 null", nil},
+		{"[1,2,]", []any{1.0, 2.0}},
+		{"{a:1,}", map[string]any{"a": 1.0}},
+		{"{$_a1: 1, ünïcödé: 2, \\u0061b: 3}", map[string]any{"$_a1": 1.0, "ünïcödé": 2.0, "ab": 3.0}},
+		{"{null: 1, true: 2, Infinity: 3}", map[string]any{"null": 1.0, "true": 2.0, "Infinity": 3.0}},
+		{"{nullable: 1, trueish: 2}", map[string]any{"nullable": 1.0, "trueish": 2.0}},
+		{"-0x1F", -31.0},
+		{"+0XFF", 255.0},
+		{"5.e2", 500.0},
+		{"-.5", -0.5},
+		{"Infinity", math.Inf(1)},
+		{"-Infinity", math.Inf(-1)},
+		{`'\x41\v\0\'\"\a'`, "A\v\x00'\"a"},
+		{`"é\/"`, "é/"},
+		{"'line\\\r\ncontinuation'", "linecontinuation"},
+		{"'line\\ continuation'", "linecontinuation"},
+		{`"tab	inside"`, "tab\tinside"},
+	}
+	for _, i := range input {
+		t.Run(i.inp, func(t *testing.T) {
+			obj, err := New([]byte(i.inp), WithJSON5())
+			assert.NoError(t, err)
+			if assert.NotNil(t, obj) {
+				assert.Equal(t, i.expected, obj.obj)
+			}
+		})
+	}
+
+	obj, err := New([]byte("[NaN, -NaN]"), WithJSON5())
+	assert.NoError(t, err)
+	for _, v := range obj.obj.([]any) {
+		assert.True(t, math.IsNaN(v.(float64)))
+	}
+}
+
+func TestJSON5Fail(t *testing.T) {
+	input := []string{
+		"[1,,]",
+		"[,]",
+		"{,}",
+		"{a:1,,}",
+		"{a b: 1}",
+		"[abc]",
+		"{1: 2}",
+		"/* unterminated",
+		"/ 1",
+		"01",
+		"0x",
+		"0xG",
+		"1.2.3",
+		".",
+		"+",
+		"-Infinityy",
+		"'unterminated",
+		"'line\nbreak'",
+		`'\1'`,
+		`'\01'`,
+		`'\x4'`,
+		"{\\x61: 1}",
+		"{🤣: 1}",
+	}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			_, err := New([]byte(in), WithJSON5())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJSON5Numbers(t *testing.T) {
+	input := `{hex: 0xFFFFFFFFFFFFFFFF, neg: -0x10, plus: +5, dot: .5}`
+
+	obj, err := New([]byte(input), WithJSON5(), WithNumberMode(NumberInteger))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"hex": uint64(math.MaxUint64), "neg": int64(-16), "plus": int64(5), "dot": 0.5}, obj.obj)
+
+	obj, err = New([]byte(input), WithJSON5(), WithNumberMode(NumberText))
+	assert.NoError(t, err)
+	hex, err := Get[uint64](obj, ".hex")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), hex)
+	neg, err := Get[int64](obj, ".neg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-16), neg)
+
+	// serialization produces strict JSON
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"dot":0.5,"hex":18446744073709551615,"neg":-16,"plus":5}`, string(out))
+
+	obj, err = New([]byte("[Infinity]"), WithJSON5())
+	assert.NoError(t, err)
+	_, err = obj.MarshalJSON()
+	assert.Error(t, err)
+}
+
+func TestJSON5Walk(t *testing.T) {
+	r := &recorder{}
+	assert.NoError(t, Walk([]byte("{a: [1,], /* c */ 'b': {},}"), r, WithJSON5()))
+	assert.Equal(t, []string{"{", "key:a", "[", "num:1", "]", "key:b", "{", "}", "}"}, r.events)
+
+	r = &recorder{skipKey: "a"}
+	assert.NoError(t, Walk([]byte("{a: {x: [1,],}, b: 2}"), r, WithJSON5()))
+	assert.Equal(t, []string{"{", "key:a", "key:b", "num:2", "}"}, r.events)
+}
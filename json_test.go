@@ -0,0 +1,213 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var streamInputs = []string{
+	"1",
+	"-12.5e+3",
+	`"str\"ingé🤣"`,
+	"true",
+	"false",
+	"null",
+	`{"key": [1, 2, {"nested": "value"}], "other": null}`,
+	"  \n\t [ ] \r\n",
+	// failing inputs, the errors have to be identical to New
+	"",
+	"[1,]",
+	"{\n  \"a\": 1,\n  \"b\" 2\n}",
+	"[\n\ttrue,\n\tfalsy\n]",
+	`["abc`,
+	`"\ud800"`,
+	"[01]",
+	"{} {}",
+	"nul",
+}
+
+// backendInputs are parsed by checkBackend with every backend, they cover
+// nesting, duplicates and all kinds of numbers
+var backendInputs = []string{
+	`null`,
+	`"str"`,
+	`-12.5e3`,
+	`18446744073709551615`,
+	`123456789012345678901234567890`,
+	`[]`,
+	`{}`,
+	`{"a": [1, [2, 3], {"b": [true, false, null]}], "c": "d\nä"}`,
+	`{"b": 1, "a": 2, "b": 3}`,
+	`{"k1": 1, "k2": 2, "k3": 3, "k4": 4, "k5": 5, "k6": 6, "k7": 7, "k8": 8, "k9": 9, "k1": 10}`,
+	`[{"x": {}}, [[[]]], 0.1, -0, 1e300]`,
+}
+
+// backendPaths are looked up in every document of backendInputs
+var backendPaths = []string{".", ".a", ".a.0", ".a.1.1", ".a.2.b", ".a.2.b.2", ".a.2.b.2.x", ".a.5", ".a.x", ".b", ".b.x", ".c", ".c.x", ".k1", ".k9", ".0", ".0.x", ".1.0.0", ".2", ".2.x", ".x.y", "..", "a"}
+
+// pointers matches addresses in the messages of errors
+var pointers = regexp.MustCompile(`0x[0-9a-f]+`)
+
+func TestNewReaderMatchesNew(t *testing.T) {
+	for _, in := range streamInputs {
+		t.Run(in, func(t *testing.T) {
+			want, wantErr := New([]byte(in), WithNumberMode(NumberText))
+			got, err := NewReader(strings.NewReader(in), WithNumberMode(NumberText))
+			assert.Equal(t, wantErr, err)
+			assert.Equal(t, want, got)
+
+			// chunked readers can only show the input read so far in
+			// SyntaxError.Snippet, everything else has to match
+			readers := []io.Reader{
+				iotest.OneByteReader(strings.NewReader(in)),
+				iotest.HalfReader(strings.NewReader(in)),
+				iotest.DataErrReader(strings.NewReader(in)),
+			}
+			for _, r := range readers {
+				got, err := NewReader(r, WithNumberMode(NumberText))
+				assert.Equal(t, want, got)
+				if wantErr == nil {
+					assert.NoError(t, err)
+					continue
+				}
+				var serr *SyntaxError
+				if assert.ErrorAs(t, err, &serr) {
+					serr.Snippet = wantErr.(*SyntaxError).Snippet
+					assert.Equal(t, wantErr, serr)
+				}
+			}
+		})
+	}
+}
+
+func TestNewReaderLarge(t *testing.T) {
+	record := `{"key1": "value", "array": [], "obj": {}, "atomArray": [11201,1e112,true,false,null,"str"]}`
+	input := "[" + strings.Repeat(record+",\n", 4*bufferSize/len(record)) + record + "]"
+	want, err := New([]byte(input))
+	assert.NoError(t, err)
+	got, err := NewReader(iotest.HalfReader(strings.NewReader(input)))
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// errors after the first window still have the correct position
+	broken := input[:len(input)-1] + ",]"
+	_, wantErr := New([]byte(broken))
+	_, err = NewReader(iotest.HalfReader(strings.NewReader(broken)))
+	assert.Error(t, err)
+	assert.Equal(t, wantErr, err)
+}
+
+func TestNewReaderLongToken(t *testing.T) {
+	long := strings.Repeat("abcdefgh", bufferSize/2)
+	input := `["` + long + `", "` + long + `\n"]`
+	obj, err := NewReader(bytes.NewReader([]byte(input)))
+	assert.NoError(t, err)
+	s, err := Get[string](obj, ".0")
+	assert.NoError(t, err)
+	assert.Equal(t, long, s)
+	s, err = Get[string](obj, ".1")
+	assert.NoError(t, err)
+	assert.Equal(t, long+"\n", s)
+}
+
+func TestNewReaderDuplicateKey(t *testing.T) {
+	// the window moves between reading the key and reporting it
+	input := "[" + strings.Repeat(" ", 2*snippetWidth) + "\n" + `{"a": 1, "a": 2}]`
+	opts := []Option{WithDuplicateKeys(DuplicateError)}
+	_, want := New([]byte(input), opts...)
+	_, err := NewReader(iotest.OneByteReader(strings.NewReader(input)), opts...)
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		serr.Snippet = want.(*SyntaxError).Snippet
+		assert.Equal(t, want, serr)
+	}
+}
+
+func TestNewReaderError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	r := io.MultiReader(strings.NewReader(`{"key": "val`), iotest.ErrReader(readErr))
+	_, err := NewReader(r)
+	assert.ErrorIs(t, err, readErr)
+
+	r = io.MultiReader(strings.NewReader(`{"key": "value"}   `), iotest.ErrReader(readErr))
+	_, err = NewReader(r)
+	assert.ErrorIs(t, err, readErr)
+}
+
+func TestNewCopiesInput(t *testing.T) {
+	input := `{"key": "value", "list": ["a", 1]}`
+	for _, opts := range [][]Option{nil, {WithLazy()}, {WithCST()}, {WithTape()}, {WithNumberMode(NumberText)}} {
+		data := []byte(input)
+		obj, err := New(data, opts...)
+		assert.NoError(t, err)
+		tolerant, _, errs := NewTolerant(data, opts...)
+		assert.Empty(t, errs)
+
+		// the caller reuses its buffer for the next request
+		copy(data, bytes.Repeat([]byte{'x'}, len(data)))
+		for _, j := range []*JSON{obj, tolerant} {
+			v, err := Get[string](j, ".key")
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+			out, err := j.Bytes()
+			assert.NoError(t, err)
+			assert.NotContains(t, string(out), "x")
+		}
+	}
+
+	data := []byte(input)
+	obj, err := New(data, WithZeroCopy())
+	assert.NoError(t, err)
+	copy(data[9:], "VALUE")
+	v, err := Get[string](obj, ".key")
+	assert.NoError(t, err)
+	assert.Equal(t, "VALUE", v)
+}
+
+// checkBackend parses every input of backendInputs with opts and backend, like
+// WithTape, and checks that the documents answer Get and MarshalJSON exactly
+// like the tree parsed with opts alone. The documents are returned in input
+// order for checks specific to the backend
+func checkBackend(t *testing.T, name string, backend Option, opts ...Option) []*JSON {
+	t.Helper()
+	docs := make([]*JSON, 0, len(backendInputs))
+	for _, in := range backendInputs {
+		tree, err := New([]byte(in), opts...)
+		assert.NoError(t, err, "%s %s", name, in)
+		doc, err := New([]byte(in), append(opts, backend)...)
+		if !assert.NoError(t, err, "%s %s", name, in) {
+			continue
+		}
+
+		// twice, backends may answer the second lookup from a cache
+		for range 2 {
+			for _, path := range backendPaths {
+				expected, expectedErr := tree.get(path)
+				v, err := doc.get(path)
+				if expectedErr != nil {
+					// pointers in the messages of materialized values differ
+					if assert.Error(t, err, "%s %s %s", name, in, path) {
+						assert.Equal(t, pointers.ReplaceAllString(expectedErr.Error(), ""), pointers.ReplaceAllString(err.Error(), ""), "%s %s %s", name, in, path)
+					}
+				} else {
+					assert.NoError(t, err, "%s %s %s", name, in, path)
+				}
+				assert.Equal(t, expected, v, "%s %s %s", name, in, path)
+			}
+		}
+
+		expected, expectedErr := tree.MarshalJSON()
+		out, err := doc.MarshalJSON()
+		assert.Equal(t, expectedErr, err, "%s %s", name, in)
+		assert.Equal(t, string(expected), string(out), "%s %s", name, in)
+		docs = append(docs, doc)
+	}
+	return docs
+}
@@ -0,0 +1,231 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithLazy makes New only validate the input and defer building values until
+// they are requested. Get parses just the objects and arrays along its path,
+// records where their members and elements start and caches everything it
+// built for the next access. Set and MarshalJSON build the whole document.
+// NewReader reads the whole input first. Unlike other documents, lazy ones
+// are not safe for concurrent use, not even for Get. WithLazy is ignored if
+// WithTape is set
+func WithLazy() Option {
+	return func(c *config) {
+		c.lazy = true
+	}
+}
+
+// lazy is the state of a document parsed WithLazy
+type lazy struct {
+	data []byte
+	cfg  config
+	root lazyNode
+}
+
+// lazyNode is a value of a lazy document, data[start:end] holds it and
+// possibly trailing whitespace
+type lazyNode struct {
+	start, end int
+	value      any
+	built      bool
+	scanned    bool           // children holds all members or elements
+	children   []lazyNode     // members or elements in input order
+	keys       map[string]int // index of the member of every key in children
+}
+
+// missing is the node of absent members and of the elements of empty arrays
+var missing = &lazyNode{built: true}
+
+// lazyDocument validates data, the input of p, and returns a lazy JSON for it.
+// The members or elements of a top level object or array are recorded while
+// validating, so the first lookup does not have to scan the input again
+func (p *parser) lazyDocument(data []byte) (*JSON, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	l := &lazy{data: data, cfg: p.l.cfg, root: lazyNode{start: p.l.start, end: len(data)}}
+	var err error
+	if p.t.Type == t_left_curly || p.t.Type == t_left_braket {
+		err = l.scan(p, &l.root, true)
+	} else {
+		err = p.validate()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.t.Type != t_eof {
+		return nil, p.l.errorf(p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
+	}
+	return &JSON{lazy: l}, nil
+}
+
+// parser returns a parser for the value of n, positioned at its first token
+func (l *lazy) parser(n *lazyNode) (parser, error) {
+	p := parser{l: lexer{data: l.data[:n.end], pos: n.start, cfg: l.cfg}}
+	return p, p.advance()
+}
+
+// value builds the value of n, or returns the cached one
+func (l *lazy) value(n *lazyNode) (any, error) {
+	if n.built {
+		return n.value, nil
+	}
+	p, err := l.parser(n)
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	n.value, n.built = v, true
+	// the built value answers all further lookups
+	n.children, n.keys = nil, nil
+	return v, nil
+}
+
+// child returns the node at key in n, with the semantics and errors of
+// indexByKey
+func (l *lazy) child(n *lazyNode, key any) (*lazyNode, error) {
+	if !n.built {
+		switch k := key.(type) {
+		case string:
+			if l.data[n.start] == '{' && l.cfg.duplicates != DuplicateCollect {
+				if err := l.scanned(n); err != nil {
+					return nil, err
+				}
+				if i, ok := n.keys[k]; ok {
+					return &n.children[i], nil
+				}
+				return missing, nil
+			}
+		case int:
+			if l.data[n.start] == '[' {
+				if err := l.scanned(n); err != nil {
+					return nil, err
+				}
+				if k < len(n.children) {
+					return &n.children[k], nil
+				} else if len(n.children) > 0 {
+					return nil, fmt.Errorf("Index %d out of range for array of length %d", k, len(n.children))
+				}
+				return missing, nil
+			}
+		}
+	}
+	// anything else is answered by the built value
+	v, err := l.value(n)
+	if err != nil {
+		return nil, err
+	}
+	if v, err = indexByKey(v, key); err != nil {
+		return nil, err
+	}
+	return &lazyNode{value: v, built: true}, nil
+}
+
+// scanned makes sure the members or elements of n are recorded
+func (l *lazy) scanned(n *lazyNode) error {
+	if n.scanned {
+		return nil
+	}
+	p, err := l.parser(n)
+	if err != nil {
+		return err
+	}
+	return l.scan(&p, n, false)
+}
+
+// scan records the location of all members or elements of the object or
+// array n starting at the current token of p. If validate is set, the
+// members and elements are checked like by validateObject and validateArray
+func (l *lazy) scan(p *parser, n *lazyNode, validate bool) error {
+	object := p.t.Type == t_left_curly
+	closing, limit, limitErr := t_right_braket, l.cfg.maxArray, ErrMaxArrayLength
+	if object {
+		closing, limit, limitErr = t_right_curly, l.cfg.maxMembers, ErrMaxObjectMembers
+		n.keys = make(map[string]int, 8)
+	}
+	if validate {
+		if err := p.enter(); err != nil {
+			return err
+		}
+		defer p.leave()
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	for count := 0; p.t.Type != t_eof && p.t.Type != closing; count++ {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(closing) {
+				break
+			}
+			if validate && limit > 0 && count >= limit {
+				return p.l.limitError(limitErr, limit, p.l.start)
+			}
+		}
+		var key string
+		if object {
+			keyStart := p.keyOffset()
+			var err error
+			if key, err = p.key(); err != nil {
+				return err
+			}
+			if _, ok := n.keys[key]; ok && validate && l.cfg.duplicates == DuplicateError {
+				return p.l.errorf(keyStart-p.l.offset, "Duplicate key %q in object", key)
+			}
+			if err := p.expect(t_colon); err != nil {
+				return err
+			}
+		}
+
+		c := lazyNode{start: p.l.start}
+		var err error
+		if validate {
+			err = p.validate()
+		} else {
+			err = p.skip()
+		}
+		if err != nil {
+			return err
+		}
+		c.end = p.l.start
+		if p.t.Type == t_eof {
+			c.end = len(p.l.data)
+		}
+		if object {
+			if _, ok := n.keys[key]; ok && l.cfg.duplicates == DuplicateFirstWins {
+				continue
+			}
+			n.keys[key] = len(n.children)
+		}
+		n.children = append(n.children, c)
+	}
+	if err := p.expect(closing); err != nil {
+		return err
+	}
+	n.scanned = true
+	return nil
+}
+
+// get looks path up, building only the values at its end
+func (l *lazy) get(path string) (any, error) {
+	keys, err := pathKeys(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	n := &l.root
+	for _, key := range keys {
+		if n, err = l.child(n, key); err != nil {
+			return nil, err
+		}
+	}
+	return l.value(n)
+}
@@ -0,0 +1,89 @@
+package libjson
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithOrderedObjects()},
+		{WithDuplicateKeys(DuplicateFirstWins)},
+		{WithDuplicateKeys(DuplicateCollect)},
+		{WithNumberMode(NumberBig)},
+		{WithJSON5()},
+	} {
+		for _, l := range checkBackend(t, "lazy", WithLazy(), opts...) {
+			assert.NotNil(t, l.lazy)
+			assert.Nil(t, l.obj)
+		}
+	}
+}
+
+func TestLazyErrors(t *testing.T) {
+	for _, in := range []string{
+		``,
+		`[1, 2,`,
+		`{"a" 1}`,
+		`{"a": 1,}`,
+		`[1] 2`,
+		`{"a": 1, "a": 2}`,
+		`[[[[1]]]]`,
+		`[1, 2, 3, 4]`,
+		`[1e400]`,
+		`{"a": "\x"}`,
+	} {
+		opts := []Option{WithDuplicateKeys(DuplicateError), WithMaxDepth(3), WithMaxArrayLength(3)}
+		_, expected := New([]byte(in), opts...)
+		_, err := New([]byte(in), append(opts, WithLazy())...)
+		assert.Error(t, err, in)
+		assert.Equal(t, expected, err, in)
+	}
+
+	in := "[" + strings.Repeat(" ", 2*snippetWidth) + "\n" + `{"a": 1, "a": 2}]`
+	opts := []Option{WithDuplicateKeys(DuplicateError), WithLazy()}
+	_, expected := New([]byte(in), opts...)
+	_, err := NewReader(iotest.OneByteReader(strings.NewReader(in)), opts...)
+	assert.Equal(t, expected, err)
+}
+
+func TestLazyPartial(t *testing.T) {
+	in := `{"large": [{"a": 1}, {"b": 2}], "small": {"x": [true]}}`
+	j, err := NewReader(iotest.OneByteReader(strings.NewReader(in)), WithLazy())
+	assert.NoError(t, err)
+
+	v, err := Get[bool](j, ".small.x.0")
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	root := &j.lazy.root
+	assert.False(t, root.built)
+	assert.True(t, root.scanned)
+	large := &root.children[root.keys["large"]]
+	assert.False(t, large.built)
+	assert.False(t, large.scanned)
+	small := &root.children[root.keys["small"]]
+	assert.False(t, small.built)
+	x := &small.children[small.keys["x"]]
+	assert.True(t, x.scanned)
+	assert.True(t, x.children[0].built)
+
+	// building a parent answers lookups from the built value
+	_, err = j.get(".small")
+	assert.NoError(t, err)
+	assert.True(t, small.built)
+	assert.Nil(t, small.children)
+	v, err = Get[bool](j, ".small.x.0")
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	assert.NoError(t, Set(j, ".large.1.b", "c"))
+	assert.Nil(t, j.lazy)
+	out, err := j.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"large":[{"a":1},{"b":"c"}],"small":{"x":[true]}}`, string(out))
+}
@@ -1,18 +1,130 @@
 package libjson
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"unicode/utf8"
 )
 
+// bufferSize is the initial size of the window a streaming lexer reads its
+// input into, the window only grows for single tokens exceeding it
+const bufferSize = 64 * 1024
+
 type lexer struct {
-	data []byte
-	pos  int
+	data  []byte
+	pos   int
+	start int // offset of the first byte of the last token returned by next
+	cfg   config
+
+	// set by Valid, escaped strings are then decoded into scratch, which is
+	// reused for the next one
+	reuse   bool
+	scratch []byte
+
+	// streaming state, only used if r is set. data is then a window into the
+	// input, refilled by fill and starting at offset. The window always
+	// contains the current token starting at start, so token values are only
+	// valid until the next call to next
+	r         io.Reader
+	rerr      error // sticky error of r, io.EOF once r is exhausted
+	read      int   // amount of bytes read from r
+	base      int   // offset cfg.maxBytes is counted from, moved by Decoder
+	offset    int   // offset of data[0] in the input
+	lines     int   // amount of newlines before data[0]
+	lineStart int   // offset of the first byte of the line containing data[0]
+}
+
+// errorf returns a *SyntaxError located at pos in l.data, errors of the
+// underlying reader take precedence, they are the cause of most unexpected
+// ends of input while streaming
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	if l.rerr != nil && l.rerr != io.EOF {
+		return l.rerr
+	}
+	err := newSyntaxError(l.data, pos, fmt.Sprintf(format, args...))
+	if l.offset > 0 {
+		if err.Line == 1 {
+			err.Column = l.offset + err.Offset - l.lineStart + 1
+		}
+		err.Line += l.lines
+		err.Offset += l.offset
+	}
+	return err
+}
+
+// limitError returns a *LimitError located at pos in l.data
+func (l *lexer) limitError(err error, limit int, pos int) error {
+	return &LimitError{Err: err, Limit: limit, Offset: l.offset + pos}
+}
+
+// fill reads more input into the window of a streaming lexer, discarding the
+// bytes before l.start, except for some context for SyntaxError.Snippet. It
+// adjusts pos and start, so indexes into l.data held by callers must be
+// recomputed afterwards. Returns false if no more input is available
+func (l *lexer) fill() bool {
+	if l.r == nil || l.rerr != nil {
+		return false
+	}
+
+	if keep := l.start - snippetWidth; keep > 0 {
+		discarded := l.data[:keep]
+		l.lines += bytes.Count(discarded, []byte{'\n'})
+		if i := bytes.LastIndexByte(discarded, '\n'); i != -1 {
+			l.lineStart = l.offset + i + 1
+		}
+		n := copy(l.data[:cap(l.data)], l.data[keep:])
+		l.data = l.data[:n]
+		l.offset += keep
+		l.pos -= keep
+		l.start -= keep
+	}
+
+	if l.data == nil {
+		l.data = make([]byte, 0, bufferSize)
+	} else if len(l.data) == cap(l.data) {
+		// the current token spans the whole window
+		l.data = slices.Grow(l.data, cap(l.data))
+	}
+
+	for {
+		buf := l.data[len(l.data):cap(l.data)]
+		if max := l.cfg.maxBytes; max > 0 {
+			// one byte past the limit may be read, it can end a number
+			if l.read-l.base > max {
+				l.rerr = &LimitError{Err: ErrMaxBytes, Limit: max, Offset: l.base + max}
+				return false
+			}
+			buf = buf[:min(len(buf), l.base+max+1-l.read)]
+		}
+		n, err := l.r.Read(buf)
+		l.data = l.data[:len(l.data)+n]
+		l.read += n
+		if err != nil {
+			l.rerr = err
+			return n > 0
+		}
+		if n > 0 {
+			return true
+		}
+	}
+}
+
+// ensure reports whether n bytes starting at l.pos are available, filling
+// the window if necessary
+func (l *lexer) ensure(n int) bool {
+	for l.pos+n > len(l.data) {
+		if !l.fill() {
+			return false
+		}
+	}
+	return true
 }
 
 func (l *lexer) advance() (byte, error) {
-	if l.pos >= len(l.data) {
+	if l.pos >= len(l.data) && !l.fill() {
 		return 0, io.EOF
 	}
 	cc := l.data[l.pos]
@@ -20,18 +132,41 @@ func (l *lexer) advance() (byte, error) {
 	return cc, nil
 }
 
+// eof is returned by next once the input is exhausted, read errors of a
+// streaming lexer are surfaced here
+func (l *lexer) eof() (token, error) {
+	if l.rerr != nil && l.rerr != io.EOF {
+		return empty, l.rerr
+	}
+	return empty, nil
+}
+
 func (l *lexer) next() (token, error) {
+	for {
+		if l.pos < len(l.data) && isSpace(l.data[l.pos]) {
+			l.pos = skipSpace(l.data, l.pos+1)
+		}
+		// whitespace is never part of a token, so fill can discard it
+		l.start = l.pos
+		if l.pos < len(l.data) || !l.fill() {
+			break
+		}
+	}
 	cc, err := l.advance()
 	if err != nil {
-		return empty, nil
+		return l.eof()
 	}
 
 	tt := t_eof
 
-	for cc == ' ' || cc == '\n' || cc == '\t' || cc == '\r' {
-		cc, err = l.advance()
-		if err != nil {
-			return empty, nil
+	if l.cfg.json5 {
+		if cc, err = l.trivia5(cc); err == io.EOF {
+			return l.eof()
+		} else if err != nil {
+			return empty, err
+		}
+		if t, ok, err := l.token5(cc); ok {
+			return t, err
 		}
 	}
 
@@ -49,78 +184,276 @@ func (l *lexer) next() (token, error) {
 	case ':':
 		tt = t_colon
 	case '"':
-		start := l.pos
-		end := start
-		for {
-			cc, err = l.advance()
-			if cc == '"' {
-				end = l.pos - 1
-				break
-			} else if err != nil {
-				return empty, errors.New("Unterminated string detected")
-			}
-		}
-		t := token{Type: t_string, Val: l.data[start:end]}
-		return t, nil
+		return l.string()
 	case 't': // this should always be the 'true' atom and is therefore optimised here
-		if l.pos+3 > len(l.data) {
-			return empty, errors.New("Failed to read the expected 'true' atom")
+		if !l.ensure(3) {
+			return empty, l.errorf(l.start, "Failed to read the expected 'true' atom")
 		}
 		if !(l.data[l.pos] == 'r' && l.data[l.pos+1] == 'u' && l.data[l.pos+2] == 'e') {
-			return empty, errors.New("Failed to read the expected 'true' atom")
+			return empty, l.errorf(l.start, "Failed to read the expected 'true' atom")
 		}
 		l.pos += 3
 		tt = t_true
 	case 'f': // this should always be the 'false' atom and is therefore optimised here
-		if l.pos+4 > len(l.data) {
-			return empty, errors.New("Failed to read the expected 'false' atom")
+		if !l.ensure(4) {
+			return empty, l.errorf(l.start, "Failed to read the expected 'false' atom")
 		}
 		if !(l.data[l.pos] == 'a' && l.data[l.pos+1] == 'l' && l.data[l.pos+2] == 's' && l.data[l.pos+3] == 'e') {
-			return empty, errors.New("Failed to read the expected 'false' atom")
+			return empty, l.errorf(l.start, "Failed to read the expected 'false' atom")
 		}
 		l.pos += 4
 		tt = t_false
 	case 'n': // this should always be the 'null' atom and is therefore optimised here
-		if l.pos+3 > len(l.data) {
-			return empty, errors.New("Failed to read the expected 'null' atom")
+		if !l.ensure(3) {
+			return empty, l.errorf(l.start, "Failed to read the expected 'null' atom")
 		}
 		if !(l.data[l.pos] == 'u' && l.data[l.pos+1] == 'l' && l.data[l.pos+2] == 'l') {
-			return empty, errors.New("Failed to read the expected 'null' atom")
+			return empty, l.errorf(l.start, "Failed to read the expected 'null' atom")
 		}
 		l.pos += 3
 		tt = t_null
 	default:
 		if cc == '-' || (cc >= '0' && cc <= '9') {
-			start := l.pos - 1
-			cc, err = l.advance()
-			if err != nil {
-				// we hit eof here
-				return token{Type: t_number, Val: []byte{l.data[start]}}, nil
+			return l.number()
+		} else {
+			return empty, l.errorf(l.start, "Unexpected character %q at this position", cc)
+		}
+	}
+
+	return token{tt, nil}, nil
+}
+
+// stringLimit enforces cfg.maxString on a string holding n decoded bytes so
+// far. Strings are checked while they are lexed, so a streaming lexer never
+// grows its window far past the limit
+func (l *lexer) stringLimit(n int) error {
+	if l.cfg.maxString > 0 && n > l.cfg.maxString {
+		return l.limitError(ErrMaxStringLength, l.cfg.maxString, l.start)
+	}
+	return nil
+}
+
+// string lexes a string, the opening '"' was already consumed. Strings without
+// escapes are returned as a sub slice of l.data, strings containing escapes
+// are decoded into a newly allocated buffer
+func (l *lexer) string() (token, error) {
+	i := l.pos
+	for {
+		i = stringEnd(l.data, i)
+		if err := l.stringLimit(i - l.start - 1); err != nil {
+			return empty, err
+		}
+		if i == len(l.data) {
+			l.pos = i
+			if !l.fill() {
+				return empty, l.errorf(l.start, "Unterminated string detected")
 			}
+			i = l.pos
+			continue
+		}
+		switch cc := l.data[i]; cc {
+		case '"':
+			l.pos = i + 1
+			return token{Type: t_string, Val: l.data[l.start+1 : i]}, nil
+		case '\\':
+			l.pos = i
+			return l.escapedString()
+		default:
+			return empty, l.errorf(i, "Unescaped control character %q in string", cc)
+		}
+	}
+}
 
-			for {
-				if (cc >= '0' && cc <= '9') || cc == '-' || cc == '+' || cc == '.' || cc == 'e' || cc == 'E' {
-					cc, err = l.advance()
-					if err != nil {
-						break
-					}
-				} else {
-					// the read at the start of the for loop iterates us too
-					// far, if we didnt break out of the loop but exited it
-					// according to its condition, thus we skip that here
-					l.pos--
-					break
-				}
+// escapedString decodes the remainder of a string starting at the first
+// escape at l.pos
+func (l *lexer) escapedString() (token, error) {
+	start := l.start + 1
+	var buf []byte
+	if l.reuse {
+		buf = append(l.scratch[:0], l.data[start:l.pos]...)
+	} else {
+		buf = make([]byte, l.pos-start, l.pos-start+16)
+		copy(buf, l.data[start:l.pos])
+	}
+	for {
+		// copy the run up to the next byte needing attention at once
+		i := stringEnd(l.data, l.pos)
+		buf = append(buf, l.data[l.pos:i]...)
+		l.pos = i
+		if err := l.stringLimit(len(buf)); err != nil {
+			return empty, err
+		}
+		cc, err := l.advance()
+		if err != nil {
+			return empty, l.errorf(l.start, "Unterminated string detected")
+		}
+		switch {
+		case cc == '"':
+			if l.reuse {
+				l.scratch = buf
 			}
+			return token{Type: t_string, Val: buf}, nil
+		case cc < 0x20:
+			return empty, l.errorf(l.pos-1, "Unescaped control character %q in string", cc)
+		case cc != '\\':
+			buf = append(buf, cc)
+			continue
+		}
 
-			t := token{Type: t_number, Val: l.data[start:l.pos]}
-			return t, nil
-		} else {
-			return empty, fmt.Errorf("Unexpected character %q at this position.", cc)
+		cc, err = l.advance()
+		if err != nil {
+			return empty, l.errorf(l.start, "Unterminated string detected")
+		}
+		switch cc {
+		case '"', '\\', '/':
+			buf = append(buf, cc)
+		case 'b':
+			buf = append(buf, '\b')
+		case 'f':
+			buf = append(buf, '\f')
+		case 'n':
+			buf = append(buf, '\n')
+		case 'r':
+			buf = append(buf, '\r')
+		case 't':
+			buf = append(buf, '\t')
+		case 'u':
+			r, err := l.unicodeEscape()
+			if err != nil {
+				return empty, err
+			}
+			buf = utf8.AppendRune(buf, r)
+		default:
+			return empty, l.errorf(l.pos-2, "Invalid escape sequence '\\%c' in string", cc)
 		}
 	}
+}
 
-	return token{tt, nil}, nil
+// unicodeEscape decodes the XXXX of a \uXXXX escape and combines utf16
+// surrogate pairs, lone surrogates are handled according to l.cfg.surrogates
+func (l *lexer) unicodeEscape() (rune, error) {
+	r, err := l.hex(4)
+	if err != nil {
+		return 0, err
+	}
+	if r < 0xD800 || r > 0xDFFF {
+		return r, nil
+	}
+	if r <= 0xDBFF && l.ensure(6) && l.data[l.pos] == '\\' && l.data[l.pos+1] == 'u' {
+		pos := l.pos
+		l.pos += 2
+		low, err := l.hex(4)
+		if err != nil {
+			return 0, err
+		}
+		if low >= 0xDC00 && low <= 0xDFFF {
+			return 0x10000 + (r-0xD800)<<10 + (low - 0xDC00), nil
+		}
+		// not a low surrogate, the escape is decoded on its own by the caller
+		l.pos = pos
+	}
+	if l.cfg.surrogates == SurrogateReplace {
+		return utf8.RuneError, nil
+	}
+	return 0, l.errorf(l.pos-6, "Lone utf16 surrogate '\\u%04X' in string", r)
+}
+
+// hex decodes the n hex digits of a \uXXXX or, in JSON5 mode, \xXX escape
+func (l *lexer) hex(n int) (rune, error) {
+	if !l.ensure(n) {
+		return 0, l.errorf(l.pos-2, "Unterminated '\\%c' escape in string", l.data[l.pos-1])
+	}
+	var r rune
+	for i, cc := range l.data[l.pos : l.pos+n] {
+		r <<= 4
+		switch {
+		case cc >= '0' && cc <= '9':
+			r |= rune(cc - '0')
+		case cc >= 'a' && cc <= 'f':
+			r |= rune(cc - 'a' + 10)
+		case cc >= 'A' && cc <= 'F':
+			r |= rune(cc - 'A' + 10)
+		default:
+			return 0, l.errorf(l.pos+i, "Invalid hex digit %q in '\\%c' escape", cc, l.data[l.pos-1])
+		}
+	}
+	l.pos += n
+	return r, nil
+}
+
+// number lexes a number according to the grammar in RFC 8259 section 6, the
+// first byte was already consumed:
+//
+//	number = [ minus ] int [ frac ] [ exp ]
+//	int    = zero / ( digit1-9 *DIGIT )
+//	frac   = decimal-point 1*DIGIT
+//	exp    = e [ minus / plus ] 1*DIGIT
+func (l *lexer) number() (token, error) {
+	if l.data[l.start] == '-' {
+		if !l.digit() {
+			return empty, l.numberError("expected digit after '-'")
+		}
+		l.pos++
+	}
+
+	// int, a leading zero can not be followed by any other digit
+	if l.data[l.pos-1] == '0' {
+		if l.digit() {
+			return empty, l.numberError("leading zeros are not allowed")
+		}
+	} else {
+		l.digits()
+	}
+
+	// frac
+	if l.ensure(1) && l.data[l.pos] == '.' {
+		l.pos++
+		if !l.digit() {
+			return empty, l.numberError("expected digit after decimal point")
+		}
+		l.digits()
+	}
+
+	// exp
+	if l.ensure(1) && (l.data[l.pos] == 'e' || l.data[l.pos] == 'E') {
+		l.pos++
+		if l.ensure(1) && (l.data[l.pos] == '+' || l.data[l.pos] == '-') {
+			l.pos++
+		}
+		if !l.digit() {
+			return empty, l.numberError("expected digit in exponent")
+		}
+		l.digits()
+	}
+
+	// numbers like 1-2, 1.2.3 or 1e5e5 are valid up until the offending
+	// character, we reject them here instead of producing two tokens
+	if l.ensure(1) {
+		switch cc := l.data[l.pos]; cc {
+		case '-', '+', '.', 'e', 'E':
+			return empty, l.numberError(fmt.Sprintf("unexpected %q", cc))
+		}
+	}
+
+	return token{Type: t_number, Val: l.data[l.start:l.pos]}, nil
+}
+
+// digit reports whether the byte at l.pos is a digit
+func (l *lexer) digit() bool {
+	return l.ensure(1) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9'
+}
+
+// digits skips all digits starting at l.pos
+func (l *lexer) digits() {
+	for l.digit() {
+		l.pos++
+	}
+}
+
+// numberError locates the error at the offending byte of the current number
+func (l *lexer) numberError(msg string) error {
+	end := min(l.pos+1, len(l.data))
+	return l.errorf(l.pos, "Invalid number %s: %s", quoteExcerpt(l.data[l.start:end]), msg)
 }
 
 // lex is only intended for tests, use lexer.next() for production code
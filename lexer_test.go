@@ -133,3 +133,111 @@ func TestLexerFail(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerStringEscapes(t *testing.T) {
+	input := []struct {
+		inp      string
+		expected string
+	}{
+		{`"no escapes"`, "no escapes"},
+		{`"\"quoted\""`, `"quoted"`},
+		{`"a\\b\/c"`, `a\b/c`},
+		{`"\b\f\n\r\t"`, "\b\f\n\r\t"},
+		{`"caf\u00e9"`, "café"},
+		{`"\u00E9\u00e9"`, "éé"},
+		{`"\ud83e\udd23"`, "🤣"},
+		{`"prefix \n suffix"`, "prefix \n suffix"},
+	}
+	for _, i := range input {
+		t.Run(i.inp, func(t *testing.T) {
+			l := &lexer{}
+			toks, err := l.lex(strings.NewReader(i.inp))
+			assert.NoError(t, err)
+			assert.EqualValues(t, []token{{Type: t_string, Val: []byte(i.expected)}}, toks)
+		})
+	}
+}
+
+func TestLexerStringSurrogates(t *testing.T) {
+	input := []string{
+		`"\ud800"`,
+		`"\udc00"`,
+		`"\ud800A"`,
+		`"\ud800\ud800"`,
+	}
+	wanted := []string{
+		"�",
+		"�",
+		"�A",
+		"��",
+	}
+	for i, in := range input {
+		t.Run(in, func(t *testing.T) {
+			l := &lexer{}
+			_, err := l.lex(strings.NewReader(in))
+			assert.Error(t, err)
+
+			l = &lexer{cfg: config{surrogates: SurrogateReplace}}
+			toks, err := l.lex(strings.NewReader(in))
+			assert.NoError(t, err)
+			assert.EqualValues(t, []token{{Type: t_string, Val: []byte(wanted[i])}}, toks)
+		})
+	}
+}
+
+func TestLexerStringFail(t *testing.T) {
+	input := []string{
+		`"\"`,
+		`"\x"`,
+		`"\u12"`,
+		`"\u12G4"`,
+		"\"\x01\"",
+		"\"line\nbreak\"",
+		`"\`,
+	}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			l := &lexer{}
+			toks, err := l.lex(strings.NewReader(in))
+			assert.Error(t, err)
+			assert.Empty(t, toks)
+		})
+	}
+}
+
+func TestLexerNumberFail(t *testing.T) {
+	input := []struct {
+		inp string
+		err string
+	}{
+		{"-", "expected digit after '-'"},
+		{"--1", "expected digit after '-'"},
+		{"-a", "expected digit after '-'"},
+		{"01", "leading zeros are not allowed"},
+		{"-007", "leading zeros are not allowed"},
+		{"1.", "expected digit after decimal point"},
+		{"1.e5", "expected digit after decimal point"},
+		{"1e", "expected digit in exponent"},
+		{"1e+", "expected digit in exponent"},
+		{"1eE2", "expected digit in exponent"},
+		{"1-2", "unexpected '-'"},
+		{"1+2", "unexpected '+'"},
+		{"1.2.3", "unexpected '.'"},
+		{"1e5e5", "unexpected 'e'"},
+	}
+	for _, i := range input {
+		t.Run(i.inp, func(t *testing.T) {
+			l := &lexer{}
+			toks, err := l.lex(strings.NewReader(i.inp))
+			assert.ErrorContains(t, err, i.err)
+			assert.Empty(t, toks)
+		})
+	}
+}
+
+func TestLexerNumberFailLong(t *testing.T) {
+	in := strings.Repeat("1", 1<<20) + "-"
+	_, err := NewReader(strings.NewReader(in))
+	assert.ErrorContains(t, err, `Invalid number "`+strings.Repeat("1", snippetWidth)+`"...: unexpected '-'`)
+	assert.Less(t, len(err.Error()), 256)
+}
@@ -0,0 +1,140 @@
+package libjson
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	input := []struct {
+		name   string
+		inp    string
+		opt    Option
+		err    error
+		offset int
+	}{
+		{"depth array", "[[[1]]]", WithMaxDepth(2), ErrMaxDepth, 2},
+		{"depth object", `{"a":{"b":{}}}`, WithMaxDepth(2), ErrMaxDepth, 10},
+		{"depth mixed", `[{"a":[]}]`, WithMaxDepth(2), ErrMaxDepth, 6},
+		{"bytes", `"0123456789"`, WithMaxBytes(8), ErrMaxBytes, 8},
+		{"string", `["short", "too long"]`, WithMaxStringLength(5), ErrMaxStringLength, 10},
+		{"string escaped", `"\u0041\u0041\u0041"`, WithMaxStringLength(2), ErrMaxStringLength, 0},
+		{"key", `{"too long": 1}`, WithMaxStringLength(5), ErrMaxStringLength, 1},
+		{"members", `{"a":1,"b":2,"c":3}`, WithMaxObjectMembers(2), ErrMaxObjectMembers, 13},
+		{"members duplicate", `{"a":1,"a":2}`, WithMaxObjectMembers(1), ErrMaxObjectMembers, 7},
+		{"array", `[1,2,3]`, WithMaxArrayLength(2), ErrMaxArrayLength, 5},
+	}
+	for _, i := range input {
+		t.Run(i.name, func(t *testing.T) {
+			_, err := New([]byte(i.inp), i.opt)
+			assert.ErrorIs(t, err, i.err)
+			var lerr *LimitError
+			if assert.ErrorAs(t, err, &lerr) {
+				assert.Equal(t, i.offset, lerr.Offset)
+			}
+		})
+	}
+}
+
+func TestLimitsNotExceeded(t *testing.T) {
+	input := `{"a":[1,2],"b":{"c":"str"}}`
+	_, err := New([]byte(input),
+		WithMaxDepth(2),
+		WithMaxBytes(len(input)),
+		WithMaxStringLength(3),
+		WithMaxObjectMembers(2),
+		WithMaxArrayLength(2),
+	)
+	assert.NoError(t, err)
+}
+
+func TestLimitsDeepNesting(t *testing.T) {
+	input := strings.Repeat("[", 1_000_000)
+	_, err := New([]byte(input), WithMaxDepth(512))
+	assert.ErrorIs(t, err, ErrMaxDepth)
+}
+
+// endlessReader simulates an unbounded upload, prefix followed by an endless
+// run of fill, spaces if fill is zero
+type endlessReader struct {
+	prefix string
+	fill   byte
+	read   int
+}
+
+func (e *endlessReader) Read(p []byte) (int, error) {
+	n := 0
+	if e.read < len(e.prefix) {
+		n = copy(p, e.prefix[e.read:])
+	}
+	fill := e.fill
+	if fill == 0 {
+		fill = ' '
+	}
+	for i := n; i < len(p); i++ {
+		p[i] = fill
+	}
+	e.read += len(p)
+	return len(p), nil
+}
+
+func TestLimitsReader(t *testing.T) {
+	r := &endlessReader{}
+	_, err := NewReader(r, WithMaxBytes(1<<16))
+	assert.True(t, errors.Is(err, ErrMaxBytes))
+	assert.LessOrEqual(t, r.read, 1<<17)
+}
+
+func TestLimitsStringReader(t *testing.T) {
+	readers := map[string]func(r io.Reader, opts []Option) error{
+		"NewReader": func(r io.Reader, opts []Option) error {
+			_, err := NewReader(r, opts...)
+			return err
+		},
+		"ValidReader": func(r io.Reader, opts []Option) error {
+			return ValidReader(r, opts...)
+		},
+		"Decoder": func(r io.Reader, opts []Option) error {
+			_, err := NewDecoder(r, opts...).Decode()
+			return err
+		},
+		"LineReader": func(r io.Reader, opts []Option) error {
+			_, err := NewLineReader(r, opts...).Next()
+			return err
+		},
+		"WalkReader": func(r io.Reader, opts []Option) error {
+			return WalkReader(r, NopHandler{}, opts...)
+		},
+	}
+	inputs := []struct {
+		prefix string
+		opts   []Option
+	}{
+		{`"`, nil},
+		{`["\n`, nil},
+		{`'`, []Option{WithJSON5()}},
+		{`['\n`, []Option{WithJSON5()}},
+		{`{`, []Option{WithJSON5()}},
+	}
+	for _, limit := range []int{1 << 10, 1 << 20} {
+		for name, read := range readers {
+			for _, in := range inputs {
+				r := &endlessReader{prefix: in.prefix, fill: 'a'}
+				err := read(r, append(in.opts, WithMaxStringLength(limit)))
+				assert.ErrorIs(t, err, ErrMaxStringLength, "%s %q", name, in.prefix)
+				// the window only holds the string up to the limit
+				assert.LessOrEqual(t, r.read, 3*limit+2*bufferSize, "%s %q", name, in.prefix)
+			}
+		}
+	}
+
+	// the window of a Parser is kept between documents
+	ps := NewParser(WithMaxStringLength(1 << 10))
+	_, err := ps.ParseReader(&endlessReader{prefix: `"`, fill: 'a'})
+	assert.ErrorIs(t, err, ErrMaxStringLength)
+	assert.LessOrEqual(t, cap(ps.p.window), bufferSize)
+}
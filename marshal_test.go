@@ -0,0 +1,71 @@
+package libjson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalMatchesEncodingJson(t *testing.T) {
+	input := []any{
+		nil,
+		true,
+		false,
+		"",
+		"string",
+		"quote\" backslash\\ control\n\t\r\x01\x1f",
+		"unicode é 🤣 \u2028 \u2029",
+		"invalid \xff utf8",
+		0.0,
+		-0.0,
+		1.5,
+		1e20,
+		1e21,
+		1e-6,
+		1e-7,
+		123456789.0,
+		-1.25e-9,
+		int64(-42),
+		uint64(math.MaxUint64),
+		[]any{},
+		[]any{1.0, "a", nil},
+		map[string]any{},
+		map[string]any{"b": 1.0, "a": []any{true}, "c": map[string]any{"z": nil}},
+		[]string{"set", "via", "Set"},
+	}
+	for _, in := range input {
+		t.Run("", func(t *testing.T) {
+			want, err := json.Marshal(in)
+			assert.NoError(t, err)
+			got, err := appendValue(nil, in)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestMarshalFail(t *testing.T) {
+	input := []any{
+		math.NaN(),
+		math.Inf(1),
+		[]any{math.Inf(-1)},
+		map[string]any{"key": make(chan int)},
+	}
+	for _, in := range input {
+		t.Run("", func(t *testing.T) {
+			_, err := appendValue(nil, in)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	input := `{"array":[1,2.5,-3e-7,"str\"ing",true,false,null],"nested":{"key":{}}}`
+	obj, err := New([]byte(input))
+	assert.NoError(t, err)
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, input, string(out))
+}
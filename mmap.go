@@ -0,0 +1,51 @@
+package libjson
+
+// File is a document parsed from a memory mapped file, see OpenFile
+type File struct {
+	*JSON
+	data   []byte
+	mapped bool
+}
+
+// OpenFile maps the file at path into memory and parses it in place, without
+// reading it into a buffer first. On systems other than Linux, and for files
+// that can not be mapped, such as pipes, the file is read instead.
+//
+// Strings and numbers of the document alias the mapping and are only valid
+// until Close, clone them to keep them longer. Values of documents parsed
+// WithTape do not alias the mapping. The file must not be modified or
+// truncated until Close, truncating it crashes the program on access
+func OpenFile(path string, opts ...Option) (*File, error) {
+	cfg := newConfig(opts)
+	data, mapped, err := mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	// the mapping is read only and not visible to the caller
+	cfg.zeroCopy = true
+	var p parser
+	j, err := p.document(data, cfg)
+	if err != nil {
+		if mapped {
+			munmap(data)
+		}
+		return nil, err
+	}
+	return &File{JSON: j, data: data, mapped: mapped}, nil
+}
+
+// Close releases the document and unmaps the file, afterwards no value taken
+// from the document may be used. Calling Close more than once is a no-op
+func (f *File) Close() error {
+	if f.JSON == nil {
+		return nil
+	}
+	f.JSON.Release()
+	f.JSON = nil
+	data, mapped := f.data, f.mapped
+	f.data, f.mapped = nil, false
+	if mapped {
+		return munmap(data)
+	}
+	return nil
+}
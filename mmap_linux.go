@@ -0,0 +1,44 @@
+//go:build linux
+
+package libjson
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmap maps the file at path read only into memory, reports false if the
+// file was read instead because it is not a regular file or empty
+func mmap(path string) ([]byte, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+	size := info.Size()
+	if !info.Mode().IsRegular() || size == 0 {
+		// empty mappings are rejected, /proc files report a size of zero
+		data, err := io.ReadAll(f)
+		return data, false, err
+	}
+	if int64(int(size)) != size {
+		return nil, false, fmt.Errorf("File %q is too large to be mapped", path)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, false, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	// the lexer reads the mapping front to back, only a hint
+	_ = syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+	return data, true, nil
+}
+
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package libjson
+
+import "os"
+
+// mmap reads the file at path, mapping files is only supported on Linux
+func mmap(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path)
+	return data, false, err
+}
+
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package libjson
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "doc.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"key": ["value", 1.5]}`), 0o644))
+
+	for _, opts := range [][]Option{nil, {WithTape()}, {WithLazy()}, {WithCST()}} {
+		f, err := OpenFile(path, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, runtime.GOOS == "linux", f.mapped)
+		s, err := Get[string](f.JSON, ".key.0")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", s)
+		n, err := Get[float64](f.JSON, ".key.1")
+		assert.NoError(t, err)
+		assert.Equal(t, 1.5, n)
+		assert.NoError(t, f.Close())
+		assert.Nil(t, f.JSON)
+		assert.NoError(t, f.Close())
+	}
+
+	_, err := OpenFile(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	assert.NoError(t, os.WriteFile(invalid, []byte(`{"key": }`), 0o644))
+	_, expected := New([]byte(`{"key": }`))
+	_, err = OpenFile(invalid)
+	assert.Equal(t, expected, err)
+
+	empty := filepath.Join(dir, "empty.json")
+	assert.NoError(t, os.WriteFile(empty, nil, 0o644))
+	_, expected = New(nil)
+	_, err = OpenFile(empty)
+	assert.Equal(t, expected, err)
+
+	large := filepath.Join(dir, "large.json")
+	assert.NoError(t, os.WriteFile(large, []byte(`"`+strings.Repeat("a", 64)+`"`), 0o644))
+	_, err = OpenFile(large, WithMaxBytes(32))
+	assert.ErrorIs(t, err, ErrMaxBytes)
+}
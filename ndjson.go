@@ -0,0 +1,229 @@
+package libjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// BadLinePolicy decides how a LineReader handles lines not containing a
+// valid JSON value
+type BadLinePolicy uint8
+
+const (
+	// BadLineError returns the *LineError from LineReader.Next, this is the
+	// default. Reading can continue with the next line afterwards
+	BadLineError BadLinePolicy = iota
+	// BadLineSkip silently skips bad lines
+	BadLineSkip
+	// BadLineCollect skips bad lines and collects their errors, see
+	// LineReader.Errors
+	BadLineCollect
+)
+
+// WithBadLines sets the handling of invalid lines for NewLineReader, see
+// BadLinePolicy
+func WithBadLines(policy BadLinePolicy) Option {
+	return func(c *config) {
+		c.badLines = policy
+	}
+}
+
+// LineError is returned for invalid lines of newline delimited JSON, Err is
+// usually a *SyntaxError located within the line
+type LineError struct {
+	Line int // 1-based line number in the input
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// LineReader reads newline delimited JSON (NDJSON, JSON Lines), one value
+// per line. Empty lines and lines only containing whitespace are skipped
+type LineReader struct {
+	r    *bufio.Reader
+	p    parser
+	cfg  config
+	rest *lineBody // line left after an error, skipped by the next call
+	line int
+	errs []*LineError
+	err  error
+}
+
+// NewLineReader returns a LineReader reading from r, the options apply to
+// every line, WithMaxBytes limits the length of a single line
+func NewLineReader(r io.Reader, opts ...Option) *LineReader {
+	return &LineReader{r: bufio.NewReader(r), cfg: newConfig(opts)}
+}
+
+// Next returns the value of the next non empty line, io.EOF once the input
+// is exhausted. Invalid lines are handled according to WithBadLines
+func (lr *LineReader) Next() (*JSON, error) {
+	for {
+		if lr.err != nil {
+			return nil, lr.err
+		}
+		if lr.rest != nil {
+			lr.rest.skip()
+			if err := lr.rest.err; err != nil && err != io.EOF {
+				lr.err = err
+				return nil, err
+			}
+			lr.rest = nil
+		}
+		if _, err := lr.r.Peek(1); err != nil {
+			lr.err = err
+			return nil, err
+		}
+		lr.line++
+
+		// lines are lexed like NewReader does, so limits bound the memory
+		// used for a single line
+		line := &lineBody{r: lr.r, blank: true}
+		j, err := lr.p.documentReader(line, lr.cfg)
+		if err == nil {
+			return j, nil
+		}
+		if line.err != nil && line.err != io.EOF {
+			lr.err = line.err
+			return nil, line.err
+		}
+		// lines of whitespace are empty, no matter how long
+		if line.blank {
+			if line.skip(); line.blank {
+				lr.rest = line
+				continue
+			}
+		}
+		// the rest of the line is skipped once the next line is requested,
+		// the error is returned without reading it
+		lr.rest = line
+
+		lerr := &LineError{Line: lr.line, Err: err}
+		switch lr.cfg.badLines {
+		case BadLineError:
+			return nil, lerr
+		case BadLineCollect:
+			lr.errs = append(lr.errs, lerr)
+		}
+	}
+}
+
+// lineBody reads a single line from r, without its line break
+type lineBody struct {
+	r     *bufio.Reader
+	done  bool  // the line break or the end of r was reached
+	blank bool  // only whitespace was read so far
+	err   error // error of r ending the line
+}
+
+// skip reads the remainder of the line
+func (l *lineBody) skip() {
+	var scratch [512]byte
+	for !l.done {
+		l.Read(scratch[:])
+	}
+}
+
+func (l *lineBody) Read(b []byte) (int, error) {
+	if l.done {
+		return 0, io.EOF
+	}
+	buf, err := l.r.Peek(max(1, min(len(b), l.r.Buffered())))
+	if len(buf) == 0 {
+		l.done, l.err = true, err
+		return 0, err
+	}
+	consumed := len(buf)
+	if i := bytes.IndexByte(buf, '\n'); i != -1 {
+		buf, consumed, l.done = bytes.TrimSuffix(buf[:i], []byte{'\r'}), i+1, true
+	} else if buf[len(buf)-1] == '\r' {
+		// a carriage return is only part of the line if no line break
+		// follows it
+		if len(buf) > 1 {
+			buf, consumed = buf[:len(buf)-1], consumed-1
+		} else if next, _ := l.r.Peek(2); len(next) == 2 && next[1] == '\n' {
+			buf, consumed, l.done = nil, 2, true
+		}
+	}
+	n := copy(b, buf)
+	l.r.Discard(consumed)
+	l.blank = l.blank && skipSpace(buf, 0) == n
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// Line returns the line number of the last line read
+func (lr *LineReader) Line() int {
+	return lr.line
+}
+
+// Errors returns the errors of all lines skipped by BadLineCollect so far
+func (lr *LineReader) Errors() []*LineError {
+	return lr.errs
+}
+
+// All iterates over the remaining values, io.EOF is not yielded. With
+// BadLineError, iteration continues after a bad line if the loop does not
+// break
+func (lr *LineReader) All() iter.Seq2[*JSON, error] {
+	return func(yield func(*JSON, error) bool) {
+		for {
+			j, err := lr.Next()
+			if err == io.EOF {
+				return
+			}
+			var lerr *LineError
+			if err != nil && !errors.As(err, &lerr) {
+				yield(nil, err)
+				return
+			}
+			if !yield(j, err) {
+				return
+			}
+		}
+	}
+}
+
+// LineWriter writes newline delimited JSON, one compact value per line
+type LineWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewLineWriter returns a LineWriter writing to w, every call to Write or
+// WriteValue results in a single call to w.Write
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{w: w}
+}
+
+// Write writes j as a single line
+func (lw *LineWriter) Write(j *JSON) error {
+	return lw.write(j.append(lw.buf[:0]))
+}
+
+// WriteValue writes v as a single line, v can be any value Set accepts
+func (lw *LineWriter) WriteValue(v any) error {
+	return lw.write(appendValue(lw.buf[:0], v))
+}
+
+// write terminates the serialized value in buf and writes it
+func (lw *LineWriter) write(buf []byte, err error) error {
+	if err != nil {
+		return err
+	}
+	lw.buf = append(buf, '\n')
+	_, err = lw.w.Write(lw.buf)
+	return err
+}
@@ -0,0 +1,145 @@
+package libjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ndjsonInput = "{\"level\":\"info\",\"msg\":\"started\"}\r\n" +
+	"\n" +
+	"[1,2,3]\n" +
+	"{\"level\": broken}\n" +
+	"   \n" +
+	"\"last line without line break\""
+
+func TestLineReader(t *testing.T) {
+	lr := NewLineReader(strings.NewReader(ndjsonInput))
+
+	j, err := lr.Next()
+	assert.NoError(t, err)
+	msg, err := Get[string](j, ".msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "started", msg)
+	assert.Equal(t, 1, lr.Line())
+
+	j, err = lr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []any{1.0, 2.0, 3.0}, j.obj)
+	assert.Equal(t, 3, lr.Line())
+
+	_, err = lr.Next()
+	var lerr *LineError
+	if assert.ErrorAs(t, err, &lerr) {
+		assert.Equal(t, 4, lerr.Line)
+		var serr *SyntaxError
+		assert.ErrorAs(t, err, &serr)
+		assert.Equal(t, 11, serr.Column)
+	}
+
+	// reading continues after bad lines
+	j, err = lr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "last line without line break", j.obj)
+	assert.Equal(t, 6, lr.Line())
+
+	_, err = lr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLineReaderBadLines(t *testing.T) {
+	lr := NewLineReader(strings.NewReader(ndjsonInput), WithBadLines(BadLineSkip))
+	values := []any{}
+	for j, err := range lr.All() {
+		assert.NoError(t, err)
+		values = append(values, j.obj)
+	}
+	assert.Len(t, values, 3)
+	assert.Empty(t, lr.Errors())
+
+	lr = NewLineReader(strings.NewReader(ndjsonInput), WithBadLines(BadLineCollect))
+	values = []any{}
+	for j, err := range lr.All() {
+		assert.NoError(t, err)
+		values = append(values, j.obj)
+	}
+	assert.Len(t, values, 3)
+	if assert.Len(t, lr.Errors(), 1) {
+		assert.Equal(t, 4, lr.Errors()[0].Line)
+	}
+}
+
+func TestLineReaderLimits(t *testing.T) {
+	long := `"` + strings.Repeat("a", 8192) + `"`
+	input := "1\n" + long + "\n2\n" + long
+	lr := NewLineReader(strings.NewReader(input), WithMaxBytes(16), WithBadLines(BadLineCollect))
+	values := []any{}
+	for j, err := range lr.All() {
+		assert.NoError(t, err)
+		values = append(values, j.obj)
+	}
+	assert.Equal(t, []any{1.0, 2.0}, values)
+	if assert.Len(t, lr.Errors(), 2) {
+		assert.Equal(t, 2, lr.Errors()[0].Line)
+		assert.ErrorIs(t, lr.Errors()[0], ErrMaxBytes)
+		assert.Equal(t, 4, lr.Errors()[1].Line)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestLineReaderReadError(t *testing.T) {
+	lr := NewLineReader(io.MultiReader(strings.NewReader("1\n"), failingReader{}))
+	j, err := lr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, j.obj)
+	_, err = lr.Next()
+	assert.EqualError(t, err, "broken pipe")
+	n := 0
+	for _, err := range lr.All() {
+		assert.EqualError(t, err, "broken pipe")
+		n++
+	}
+	assert.Equal(t, 1, n)
+}
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+	lw := NewLineWriter(&buf)
+
+	j, err := New([]byte("{\n  \"a\": [1, 2],\n  \"b\": \"multi\\nline\"\n}"))
+	assert.NoError(t, err)
+	assert.NoError(t, lw.Write(j))
+	assert.NoError(t, lw.WriteValue([]any{"x", nil}))
+	assert.NoError(t, lw.WriteValue(map[string]any{"n": int64(3)}))
+	assert.Equal(t, "{\"a\":[1,2],\"b\":\"multi\\nline\"}\n[\"x\",null]\n{\"n\":3}\n", buf.String())
+
+	// round trip
+	lr := NewLineReader(&buf)
+	n := 0
+	for _, err := range lr.All() {
+		assert.NoError(t, err)
+		n++
+	}
+	assert.Equal(t, 3, n)
+}
+
+func TestLineWriterBackends(t *testing.T) {
+	in := "{\"a\": [1, {\"b\": null}], \"c\": \"d\"}\n[true, \"x\"]\n\"s\"\n"
+	for _, opts := range [][]Option{{WithTape()}, {WithLazy()}} {
+		var buf bytes.Buffer
+		lw := NewLineWriter(&buf)
+		for j, err := range NewLineReader(strings.NewReader(in), opts...).All() {
+			assert.NoError(t, err)
+			assert.Nil(t, j.obj)
+			assert.NoError(t, lw.Write(j))
+		}
+		assert.Equal(t, "{\"a\":[1,{\"b\":null}],\"c\":\"d\"}\n[true,\"x\"]\n\"s\"\n", buf.String())
+	}
+}
@@ -0,0 +1,185 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// NumberMode decides the go type the parser produces for JSON numbers
+type NumberMode uint8
+
+const (
+	// NumberFloat64 parses every number into a float64, this is the default
+	NumberFloat64 NumberMode = iota
+	// NumberInteger parses integers fitting into an int64 to int64, positive
+	// integers only fitting into an uint64 to uint64 and everything else to
+	// float64
+	NumberInteger
+	// NumberText keeps every number as a Number holding its original text,
+	// conversion happens on demand via its accessors
+	NumberText
+	// NumberBig parses integers to *big.Int and keeps everything else as a
+	// Number holding its exact decimal text, which serializes in linear time
+	// for any exponent. Get[*big.Float] converts it with a precision large
+	// enough to hold every digit
+	NumberBig
+)
+
+// WithNumberMode sets the go type numbers are parsed into, see NumberMode
+func WithNumberMode(mode NumberMode) Option {
+	return func(c *config) {
+		c.numbers = mode
+	}
+}
+
+// Number is a JSON number in its original textual representation, as
+// produced by NumberText. It serializes to exactly this text
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns n as an int64, fails for fractions, exponents and integers
+// not fitting into an int64
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), n.base(), 64)
+}
+
+// Uint64 returns n as an uint64, fails for negative numbers, fractions,
+// exponents and integers not fitting into an uint64
+func (n Number) Uint64() (uint64, error) {
+	return strconv.ParseUint(string(n), n.base(), 64)
+}
+
+// Float64 returns n as the nearest float64
+func (n Number) Float64() (float64, error) {
+	if isHex(string(n)) {
+		i, ok := new(big.Int).SetString(string(n), 0)
+		if !ok {
+			return 0, strconv.ErrSyntax
+		}
+		f, _ := new(big.Float).SetInt(i).Float64()
+		return f, nil
+	}
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// base returns the base for strconv, JSON5 numbers can be hexadecimal
+func (n Number) base() int {
+	if isHex(string(n)) {
+		// 0 makes strconv accept the 0x prefix
+		return 0
+	}
+	return 10
+}
+
+// MarshalJSON returns n as is, except for JSON5 only spellings which are
+// converted to strict JSON
+func (n Number) MarshalJSON() ([]byte, error) {
+	if !strictNumber(string(n)) {
+		return appendNumber5(nil, n)
+	}
+	return []byte(n), nil
+}
+
+// isInteger reports whether the lexed number b has neither a fraction nor an
+// exponent
+func isInteger(b []byte) bool {
+	for _, c := range b {
+		if c == '.' || c == 'e' || c == 'E' {
+			return false
+		}
+	}
+	return true
+}
+
+// toNumber converts all number representations the parser produces into a
+// Number
+func toNumber(val any) (Number, bool) {
+	switch v := val.(type) {
+	case Number:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return Number(strconv.FormatFloat(v, 'f', -1, 64)), true
+		}
+		return Number(strconv.FormatFloat(v, 'g', -1, 64)), true
+	case int64:
+		return Number(strconv.FormatInt(v, 10)), true
+	case uint64:
+		return Number(strconv.FormatUint(v, 10)), true
+	case *big.Int:
+		return Number(v.String()), true
+	case *big.Float:
+		return Number(v.Text('g', -1)), true
+	default:
+		return "", false
+	}
+}
+
+// cast asserts val to T, numbers are additionally converted between their
+// representations if this is possible without losing precision, so for
+// instance Get[int64] works for integers parsed as float64 and Number
+func cast[T any](val any) (T, error) {
+	if castVal, ok := val.(T); ok {
+		return castVal, nil
+	}
+	var e T
+	n, ok := toNumber(val)
+	if !ok {
+		return e, fmt.Errorf("Expected value of type %T, got type %T", e, val)
+	}
+	var r any
+	var err error
+	switch any(e).(type) {
+	case int64:
+		r, err = n.Int64()
+	case uint64:
+		r, err = n.Uint64()
+	case float64:
+		var f float64
+		f, err = n.Float64()
+		if err == nil && isInteger([]byte(n)) && strconv.FormatFloat(f, 'f', -1, 64) != string(n) {
+			err = errors.New("integer does not fit into float64 without losing precision")
+		}
+		r = f
+	case Number:
+		r = n
+	case *big.Int:
+		i, ok := new(big.Int).SetString(string(n), 10)
+		if !ok {
+			err = errors.New("not an integer")
+		}
+		r = i
+	case *big.Float:
+		r, err = parseBigFloat(string(n))
+	default:
+		return e, fmt.Errorf("Expected value of type %T, got type %T", e, val)
+	}
+	if err != nil {
+		return e, fmt.Errorf("Can not convert %T::%v to %T: %w", val, val, e, err)
+	}
+	return r.(T), nil
+}
+
+// parseBigFloat parses s with a precision large enough to hold every decimal
+// digit of its mantissa, this makes Text('g', -1) reproduce the exact value
+func parseBigFloat(s string) (*big.Float, error) {
+	digits := 0
+	for _, c := range []byte(s) {
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	// log2(10) < 3.33, round up generously
+	prec := uint(digits)*4 + 64
+	f, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	return f, err
+}
@@ -0,0 +1,211 @@
+package libjson
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumberModeInteger(t *testing.T) {
+	input := []string{
+		"0",
+		"-0",
+		"12",
+		"-12",
+		"9007199254740993",
+		"-9223372036854775808",
+		"18446744073709551615",
+		"18446744073709551616",
+		"1.5",
+		"1e3",
+	}
+	wanted := []any{
+		int64(0),
+		int64(0),
+		int64(12),
+		int64(-12),
+		int64(9007199254740993),
+		int64(math.MinInt64),
+		uint64(math.MaxUint64),
+		1.8446744073709552e19,
+		1.5,
+		1000.0,
+	}
+	for i, in := range input {
+		t.Run(in, func(t *testing.T) {
+			p := &parser{l: lexer{data: []byte(in), cfg: config{numbers: NumberInteger}}}
+			out, err := p.parse()
+			assert.NoError(t, err)
+			assert.Equal(t, wanted[i], out)
+		})
+	}
+}
+
+func TestNumberModeText(t *testing.T) {
+	obj, err := New([]byte(`{"id": 9007199254740993, "price": 1.50, "exp": -1E+400}`), WithNumberMode(NumberText))
+	assert.NoError(t, err)
+
+	id, err := Get[Number](obj, ".id")
+	assert.NoError(t, err)
+	assert.Equal(t, Number("9007199254740993"), id)
+	i, err := id.Int64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9007199254740993), i)
+
+	price, err := Get[Number](obj, ".price")
+	assert.NoError(t, err)
+	f, err := price.Float64()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+	_, err = price.Int64()
+	assert.Error(t, err)
+
+	exp, err := Get[Number](obj, ".exp")
+	assert.NoError(t, err)
+	assert.Equal(t, "-1E+400", exp.String())
+
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"id":9007199254740993`)
+	assert.Contains(t, string(out), `"price":1.50`)
+	assert.Contains(t, string(out), `"exp":-1E+400`)
+}
+
+func TestNumberModeEndToEnd(t *testing.T) {
+	inputs := map[NumberMode]string{
+		NumberInteger: `[9007199254740993,-9223372036854775808,18446744073709551615,{"id":12345678901234567890},1.5,[-12]]`,
+		NumberText:    `[9007199254740993,-9223372036854775808,18446744073709551615,{"id":12345678901234567890},1.50,[-1E+400,-0]]`,
+	}
+	for mode, in := range inputs {
+		for _, opts := range [][]Option{nil, {WithOrderedObjects()}, {WithTape()}, {WithLazy()}} {
+			obj, err := New([]byte(in), append(opts, WithNumberMode(mode))...)
+			assert.NoError(t, err, in)
+
+			id, err := Get[uint64](obj, ".3.id")
+			assert.NoError(t, err, in)
+			assert.Equal(t, uint64(12345678901234567890), id)
+			first, err := Get[int64](obj, ".0")
+			assert.NoError(t, err, in)
+			assert.Equal(t, int64(9007199254740993), first)
+
+			out, err := obj.MarshalJSON()
+			assert.NoError(t, err, in)
+			assert.Equal(t, in, string(out))
+		}
+	}
+}
+
+func TestNumberGet(t *testing.T) {
+	for _, mode := range []NumberMode{NumberFloat64, NumberInteger, NumberText} {
+		obj, err := New([]byte(`{"small": 42, "big": 9007199254740993, "frac": 0.25}`), WithNumberMode(mode))
+		assert.NoError(t, err)
+
+		small, err := Get[int64](obj, ".small")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), small)
+
+		usmall, err := Get[uint64](obj, ".small")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(42), usmall)
+
+		frac, err := Get[float64](obj, ".frac")
+		assert.NoError(t, err)
+		assert.Equal(t, 0.25, frac)
+
+		_, err = Get[int64](obj, ".frac")
+		assert.Error(t, err)
+
+		_, err = Get[string](obj, ".small")
+		assert.Error(t, err)
+
+		big, err := Get[int64](obj, ".big")
+		if mode == NumberFloat64 {
+			// precision was lost while parsing
+			assert.Equal(t, int64(9007199254740992), big)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, int64(9007199254740993), big)
+			_, err = Get[float64](obj, ".big")
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestNumberModeBig(t *testing.T) {
+	input := `{"int": 123456789012345678901234567890, "dec": 0.1000000000000000000000000000000000001, "exp": 1.5e-400, "small": 3}`
+	obj, err := New([]byte(input), WithNumberMode(NumberBig))
+	assert.NoError(t, err)
+
+	i, err := Get[*big.Int](obj, ".int")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456789012345678901234567890", i.String())
+
+	dec, err := Get[*big.Float](obj, ".dec")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1000000000000000000000000000000000001", dec.Text('g', -1))
+
+	// integers are converted on demand
+	small, err := Get[*big.Float](obj, ".small")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", small.Text('g', -1))
+	small64, err := Get[int64](obj, ".small")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), small64)
+
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"dec":0.1000000000000000000000000000000000001,"exp":1.5e-400,"int":123456789012345678901234567890,"small":3}`, string(out))
+
+	assert.NoError(t, Set(obj, ".int", new(big.Int).Lsh(big.NewInt(1), 100)))
+	out, err = obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), `"int":1267650600228229401496703205376`)
+}
+
+func TestNumberModeBigRoundTrip(t *testing.T) {
+	input := []string{
+		"0.1",
+		"-0.3",
+		"3.141592653589793238462643383279502884197",
+		"1e+1000",
+		"-2.5e-1000",
+		"123456789.987654321",
+	}
+	for _, in := range input {
+		t.Run(in, func(t *testing.T) {
+			obj, err := New([]byte(in), WithNumberMode(NumberBig))
+			assert.NoError(t, err)
+			out, err := obj.MarshalJSON()
+			assert.NoError(t, err)
+			want, _, _ := big.ParseFloat(in, 10, 4096, big.ToNearestEven)
+			got, _, err := big.ParseFloat(string(out), 10, 4096, big.ToNearestEven)
+			assert.NoError(t, err)
+			assert.Zero(t, want.Cmp(got), "%s != %s", in, out)
+		})
+	}
+}
+
+func TestNumberModeBigHugeExponent(t *testing.T) {
+	// formatting these as *big.Float takes seconds
+	start := time.Now()
+	obj, err := New([]byte(`[1e4567890, -1.5e-4567890]`), WithNumberMode(NumberBig))
+	assert.NoError(t, err)
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `[1e4567890,-1.5e-4567890]`, string(out))
+
+	obj, err = New([]byte(`[+.5e4567890, 5.e-4567890]`), WithJSON5(), WithNumberMode(NumberBig))
+	assert.NoError(t, err)
+	out, err = obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `[0.5e4567890,5.0e-4567890]`, string(out))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	f, err := Get[*big.Float](obj, ".0")
+	assert.NoError(t, err)
+	exp := f.MantExp(nil)
+	assert.Greater(t, exp, 15_000_000)
+}
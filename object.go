@@ -1,9 +1,9 @@
 package libjson
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
@@ -17,12 +17,7 @@ func Get[T any](obj *JSON, path string) (T, error) {
 		var e T
 		return e, err
 	}
-	if castVal, ok := val.(T); !ok {
-		var e T
-		return e, fmt.Errorf("Expected value of type %T, got type %T", e, val)
-	} else {
-		return castVal, nil
-	}
+	return cast[T](val)
 }
 
 func Compile[T any](obj *JSON, path string) (func() (T, error), error) {
@@ -36,12 +31,7 @@ func Compile[T any](obj *JSON, path string) (func() (T, error), error) {
 			var e T
 			return e, err
 		}
-		if castVal, ok := val.(T); !ok {
-			var e T
-			return e, fmt.Errorf("Expected value of type %T, got type %T", e, val)
-		} else {
-			return castVal, nil
-		}
+		return cast[T](val)
 	}, nil
 }
 
@@ -51,7 +41,7 @@ func indexByKey(data any, key any) (any, error) {
 		return nil, errors.New("Can not index into null")
 	case string:
 		return nil, errors.New("Can not index into string")
-	case float64:
+	case float64, int64, uint64, Number, *big.Int, *big.Float:
 		return nil, errors.New("Can not index into number")
 	case []any:
 		if len(v) == 0 {
@@ -59,6 +49,8 @@ func indexByKey(data any, key any) (any, error) {
 		}
 		if k, ok := key.(int); !ok {
 			return nil, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
+		} else if k >= len(v) {
+			return nil, fmt.Errorf("Index %d out of range for array of length %d", k, len(v))
 		} else {
 			return v[k], nil
 		}
@@ -71,21 +63,28 @@ func indexByKey(data any, key any) (any, error) {
 		} else {
 			return v[k], nil
 		}
+	case *Object:
+		if k, ok := key.(string); !ok {
+			return nil, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
+		} else {
+			val, _ := v.Get(k)
+			return val, nil
+		}
 	default:
 		return nil, fmt.Errorf("Unsupported %T, can not index", data)
 	}
 }
 
-func parsePath(path string) (func(any) (any, error), error) {
+// pathKeys splits path into its keys, keys starting with a digit are
+// converted to int for indexing into arrays
+func pathKeys(path string) ([]any, error) {
 	if len(path) == 0 {
 		return nil, errors.New("Unexpected index syntax, top level element is available via '.'")
 	}
 
 	// fast paths for '.' path / parent access
 	if len(path) == 1 && path[0] == '.' {
-		return func(a any) (any, error) {
-			return a, nil
-		}, nil
+		return nil, nil
 	}
 
 	// skip first . because we handled that above
@@ -102,16 +101,27 @@ func parsePath(path string) (func(any) (any, error), error) {
 		}
 	}
 
+	for i, k := range keys {
+		key := k.(string)
+		if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+			if k1, err := strconv.ParseInt(key, 10, 32); err == nil {
+				keys[i] = int(k1)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+func parsePath(path string) (func(any) (any, error), error) {
+	keys, err := pathKeys(path)
+	if err != nil {
+		return nil, err
+	}
+
 	return func(a any) (any, error) {
 		val := a
 		for _, k := range keys {
-			key := k.(string)
-			if key[0] >= '0' && key[0] <= '9' {
-				if k1, err := strconv.ParseInt(key, 10, 32); err == nil {
-					k = int(k1)
-				}
-			}
-
 			if v, err := indexByKey(val, k); err != nil {
 				return nil, err
 			} else {
@@ -122,11 +132,54 @@ func parsePath(path string) (func(any) (any, error), error) {
 	}, nil
 }
 
+// setByKey replaces the value at key in data, arrays are not grown
+func setByKey(data any, key any, value any) error {
+	switch v := data.(type) {
+	case []any:
+		if k, ok := key.(int); !ok {
+			return fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
+		} else if k >= len(v) {
+			return fmt.Errorf("Index %d out of range for array of length %d", k, len(v))
+		} else {
+			v[k] = value
+		}
+	case map[string]any:
+		if k, ok := key.(string); !ok {
+			return fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
+		} else {
+			v[k] = value
+		}
+	case *Object:
+		if k, ok := key.(string); !ok {
+			return fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, data, data)
+		} else {
+			v.Set(k, value)
+		}
+	default:
+		return fmt.Errorf("Can not set %v on %T, expected array or object", key, data)
+	}
+	return nil
+}
+
 type JSON struct {
-	obj any
+	obj   any
+	cst   *cst   // only set if parsed WithCST
+	arena *arena // only set if parsed WithArena
+	tape  *tape  // only set if parsed WithTape, obj is nil until Set
+	lazy  *lazy  // only set if parsed WithLazy, obj is nil until Set
 }
 
 func (j *JSON) get(path string) (any, error) {
+	if j.tape != nil {
+		i, err := j.tape.lookup(path)
+		if err != nil {
+			return nil, err
+		}
+		return j.tape.value(i), nil
+	}
+	if j.lazy != nil {
+		return j.lazy.get(path)
+	}
 	f, err := parsePath(path)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
@@ -135,7 +188,47 @@ func (j *JSON) get(path string) (any, error) {
 }
 
 func (j *JSON) set(path string, value any) error {
-	// TODO:
+	keys, err := pathKeys(path)
+	if err != nil {
+		return fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	// the syntax tree is checked up front, so Set changes both trees or
+	// neither
+	var edit func()
+	if j.cst != nil {
+		raw, err := appendValue(nil, value)
+		if err != nil {
+			return err
+		}
+		if edit, err = j.cst.set(keys, raw); err != nil {
+			return err
+		}
+	}
+	if j.tape != nil {
+		j.obj, j.tape = j.tape.value(0), nil
+	}
+	if j.lazy != nil {
+		if j.obj, err = j.lazy.value(&j.lazy.root); err != nil {
+			return err
+		}
+		j.lazy = nil
+	}
+	if len(keys) == 0 {
+		j.obj = value
+	} else {
+		parent := j.obj
+		for _, k := range keys[:len(keys)-1] {
+			if parent, err = indexByKey(parent, k); err != nil {
+				return err
+			}
+		}
+		if err = setByKey(parent, keys[len(keys)-1], value); err != nil {
+			return err
+		}
+	}
+	if edit != nil {
+		edit()
+	}
 	return nil
 }
 
@@ -146,5 +239,30 @@ func (j *JSON) compile() (func() (any, error), error) {
 }
 
 func (j *JSON) MarshalJSON() ([]byte, error) {
-	return json.Marshal(j.obj)
+	return j.append(nil)
+}
+
+// append serializes the document like appendValue, whatever backs it
+func (j *JSON) append(buf []byte) ([]byte, error) {
+	if j.tape != nil {
+		return j.tape.append(buf, 0)
+	}
+	if j.lazy != nil {
+		obj, err := j.lazy.value(&j.lazy.root)
+		if err != nil {
+			return nil, err
+		}
+		return appendValue(buf, obj)
+	}
+	return appendValue(buf, j.obj)
+}
+
+// Bytes returns the input of a document parsed WithCST, in its original
+// formatting and with the edits made via Set applied. For any other document
+// it is the same as MarshalJSON
+func (j *JSON) Bytes() ([]byte, error) {
+	if j.cst == nil {
+		return j.MarshalJSON()
+	}
+	return j.cst.bytes(), nil
 }
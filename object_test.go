@@ -69,3 +69,44 @@ func TestStandardFail(t *testing.T) {
 		})
 	}
 }
+
+func TestObjectEscapedKeys(t *testing.T) {
+	obj, err := New([]byte(`{"k\"ey": "välue"}`))
+	assert.NoError(t, err)
+	out, err := Get[string](obj, `.k"ey`)
+	assert.NoError(t, err)
+	assert.Equal(t, "välue", out)
+
+	_, err = New([]byte(`"\udead"`))
+	assert.Error(t, err)
+	obj, err = New([]byte(`"\udead"`), WithSurrogatePolicy(SurrogateReplace))
+	assert.NoError(t, err)
+	out, err = Get[string](obj, ".")
+	assert.NoError(t, err)
+	assert.Equal(t, "�", out)
+}
+
+func TestObjectSet(t *testing.T) {
+	obj, err := New([]byte(`{"hello": {"world": ["hi", "ho"]}, "n": 1}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, Set(obj, ".hello.world.1", "heyho"))
+	out, err := Get[string](obj, ".hello.world.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "heyho", out)
+
+	assert.NoError(t, Set(obj, ".hello.new", true))
+	b, err := Get[bool](obj, ".hello.new")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	assert.Error(t, Set(obj, ".hello.world.2", "out of range"))
+	assert.Error(t, Set(obj, ".hello.world.key", "not an index"))
+	assert.Error(t, Set(obj, ".n.key", "not a container"))
+	assert.Error(t, Set(obj, "", "empty path"))
+
+	assert.NoError(t, Set(obj, ".", []any{1.0}))
+	f, err := Get[float64](obj, ".0")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, f)
+}
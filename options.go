@@ -0,0 +1,151 @@
+package libjson
+
+import "bytes"
+
+// Option configures the lexer and the parser, pass any number of them to New
+// and NewReader
+type Option func(*config)
+
+// config holds the state all options modify, its zero value is the strict
+// RFC 8259 behaviour libjson defaults to
+type config struct {
+	surrogates SurrogatePolicy
+	numbers    NumberMode
+	duplicates DuplicateKeyPolicy
+	ordered    bool
+	badLines   BadLinePolicy
+	json5      bool
+	cst        bool
+	workers    int
+	arena      bool
+	tape       bool
+	lazy       bool
+	zeroCopy   bool
+
+	// limits, zero means unlimited
+	maxDepth   int
+	maxBytes   int
+	maxString  int
+	maxMembers int
+	maxArray   int
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// SurrogatePolicy decides what happens to \uXXXX escapes encoding a lone
+// utf16 surrogate, such as "\uD800" without a following low surrogate
+type SurrogatePolicy uint8
+
+const (
+	// SurrogateError rejects lone surrogates, this is the default
+	SurrogateError SurrogatePolicy = iota
+	// SurrogateReplace replaces lone surrogates with U+FFFD
+	SurrogateReplace
+)
+
+// WithSurrogatePolicy sets the handling of lone utf16 surrogates in string
+// escapes, see SurrogatePolicy
+func WithSurrogatePolicy(policy SurrogatePolicy) Option {
+	return func(c *config) {
+		c.surrogates = policy
+	}
+}
+
+// WithMaxDepth limits the nesting of objects and arrays to depth levels,
+// exceeding it results in ErrMaxDepth. Zero disables the limit
+func WithMaxDepth(depth int) Option {
+	return func(c *config) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxBytes limits the size of the input to n bytes, exceeding it results
+// in ErrMaxBytes. NewReader stops reading after n+1 bytes. Zero disables the
+// limit
+func WithMaxBytes(n int) Option {
+	return func(c *config) {
+		c.maxBytes = n
+	}
+}
+
+// WithMaxStringLength limits the length of decoded strings and object keys to
+// n bytes, exceeding it results in ErrMaxStringLength. Zero disables the limit
+func WithMaxStringLength(n int) Option {
+	return func(c *config) {
+		c.maxString = n
+	}
+}
+
+// WithMaxObjectMembers limits the amount of members per object to n,
+// exceeding it results in ErrMaxObjectMembers. Zero disables the limit
+func WithMaxObjectMembers(n int) Option {
+	return func(c *config) {
+		c.maxMembers = n
+	}
+}
+
+// WithMaxArrayLength limits the amount of elements per array to n, exceeding
+// it results in ErrMaxArrayLength. Zero disables the limit
+func WithMaxArrayLength(n int) Option {
+	return func(c *config) {
+		c.maxArray = n
+	}
+}
+
+// DuplicateKeyPolicy decides what happens to keys occurring more than once in
+// the same object, RFC 8259 leaves this up to the implementation
+type DuplicateKeyPolicy uint8
+
+const (
+	// DuplicateLastWins keeps the value of the last occurrence, this is the
+	// default
+	DuplicateLastWins DuplicateKeyPolicy = iota
+	// DuplicateError rejects the input with a *SyntaxError naming the key
+	DuplicateError
+	// DuplicateFirstWins keeps the value of the first occurrence
+	DuplicateFirstWins
+	// DuplicateCollect collects the values of all occurrences into an array
+	DuplicateCollect
+)
+
+// WithDuplicateKeys sets the handling of duplicate object keys, see
+// DuplicateKeyPolicy
+func WithDuplicateKeys(policy DuplicateKeyPolicy) Option {
+	return func(c *config) {
+		c.duplicates = policy
+	}
+}
+
+// WithZeroCopy makes New, NewTolerant and Parser.Parse use data as is,
+// instead of parsing a private copy of it. Strings, numbers and the trivia of
+// WithCST then alias data, which must not be modified as long as the
+// document or any of its values are in use. Escaped strings and documents
+// parsed WithTape never alias data
+func WithZeroCopy() Option {
+	return func(c *config) {
+		c.zeroCopy = true
+	}
+}
+
+// own returns data, or a copy of it unless WithZeroCopy is set, so parsed
+// values do not change if the caller reuses data
+func (c *config) own(data []byte) []byte {
+	if c.zeroCopy {
+		return data
+	}
+	return bytes.Clone(data)
+}
+
+// WithOrderedObjects makes the parser produce *Object instead of
+// map[string]any for JSON objects, keeping keys in the order of the input
+func WithOrderedObjects() Option {
+	return func(c *config) {
+		c.ordered = true
+	}
+}
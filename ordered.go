@@ -0,0 +1,113 @@
+package libjson
+
+import (
+	"iter"
+)
+
+// indexThreshold is the amount of members starting at which Object builds a
+// map for lookups, below that a linear search is faster than hashing
+const indexThreshold = 8
+
+// Object is a JSON object keeping its members in insertion order, the parser
+// produces it instead of map[string]any if WithOrderedObjects is set. The
+// zero value is an empty object ready to use
+type Object struct {
+	keys   []string
+	values []any
+	index  map[string]int // built lazily once the object is large enough
+}
+
+// NewObject returns an empty Object with room for size members
+func NewObject(size int) *Object {
+	return &Object{keys: make([]string, 0, size), values: make([]any, 0, size)}
+}
+
+func (o *Object) find(key string) int {
+	if len(o.keys) < indexThreshold {
+		for i, k := range o.keys {
+			if k == key {
+				return i
+			}
+		}
+		return -1
+	}
+	if o.index == nil {
+		o.index = make(map[string]int, len(o.keys))
+		for i, k := range o.keys {
+			o.index[k] = i
+		}
+	}
+	if i, ok := o.index[key]; ok {
+		return i
+	}
+	return -1
+}
+
+// Get returns the value of key and whether key is a member of o
+func (o *Object) Get(key string) (any, bool) {
+	if i := o.find(key); i != -1 {
+		return o.values[i], true
+	}
+	return nil, false
+}
+
+// Set replaces the value of key in place or appends key if it is not yet a
+// member of o
+func (o *Object) Set(key string, value any) {
+	if i := o.find(key); i != -1 {
+		o.values[i] = value
+		return
+	}
+	if o.index != nil {
+		o.index[key] = len(o.keys)
+	}
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, value)
+}
+
+// Delete removes key from o, the order of the remaining members is kept
+func (o *Object) Delete(key string) {
+	i := o.find(key)
+	if i == -1 {
+		return
+	}
+	o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	o.values = append(o.values[:i], o.values[i+1:]...)
+	o.index = nil
+}
+
+// Len returns the amount of members of o
+func (o *Object) Len() int {
+	return len(o.keys)
+}
+
+// Keys returns the keys of o in insertion order, the slice must not be
+// modified
+func (o *Object) Keys() []string {
+	return o.keys
+}
+
+// All iterates over the members of o in insertion order
+func (o *Object) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for i, k := range o.keys {
+			if !yield(k, o.values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// Map converts o into a map[string]any, nested objects are not converted
+func (o *Object) Map() map[string]any {
+	m := make(map[string]any, len(o.keys))
+	for i, k := range o.keys {
+		m[k] = o.values[i]
+	}
+	return m
+}
+
+// MarshalJSON serializes o with its keys in insertion order
+func (o *Object) MarshalJSON() ([]byte, error) {
+	return appendValue(nil, o)
+}
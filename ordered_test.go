@@ -0,0 +1,99 @@
+package libjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedObject(t *testing.T) {
+	o := &Object{}
+	o.Set("z", 1.0)
+	o.Set("a", 2.0)
+	o.Set("m", 3.0)
+	o.Set("z", 4.0)
+	assert.Equal(t, []string{"z", "a", "m"}, o.Keys())
+	assert.Equal(t, 3, o.Len())
+
+	v, ok := o.Get("z")
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, v)
+	_, ok = o.Get("missing")
+	assert.False(t, ok)
+
+	o.Delete("a")
+	assert.Equal(t, []string{"z", "m"}, o.Keys())
+	assert.Equal(t, map[string]any{"z": 4.0, "m": 3.0}, o.Map())
+
+	keys := []string{}
+	for k := range o.All() {
+		keys = append(keys, k)
+	}
+	assert.Equal(t, []string{"z", "m"}, keys)
+}
+
+func TestOrderedObjectIndex(t *testing.T) {
+	o := NewObject(0)
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9"}
+	for i, k := range keys {
+		o.Set(k, i)
+	}
+	for i, k := range keys {
+		v, ok := o.Get(k)
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	o.Set("k10", 10)
+	v, ok := o.Get("k10")
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	o.Delete("k0")
+	v, ok = o.Get("k9")
+	assert.True(t, ok)
+	assert.Equal(t, 9, v)
+	_, ok = o.Get("k0")
+	assert.False(t, ok)
+}
+
+func TestOrderedObjectParse(t *testing.T) {
+	input := `{"zebra":1,"apple":{"y":true,"x":null},"mango":[{"b":"c","a":"d"}]}`
+	obj, err := New([]byte(input), WithOrderedObjects())
+	assert.NoError(t, err)
+
+	root, err := Get[*Object](obj, ".")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"zebra", "apple", "mango"}, root.Keys())
+
+	x, err := Get[bool](obj, ".apple.y")
+	assert.NoError(t, err)
+	assert.True(t, x)
+	a, err := Get[string](obj, ".mango.0.a")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", a)
+
+	out, err := obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, input, string(out))
+
+	assert.NoError(t, Set(obj, ".apple.x", "set"))
+	assert.NoError(t, Set(obj, ".apple.new", 1.5))
+	out, err = obj.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"zebra":1,"apple":{"y":true,"x":"set","new":1.5},"mango":[{"b":"c","a":"d"}]}`, string(out))
+}
+
+func TestOrderedObjectDuplicates(t *testing.T) {
+	input := `{"b":1,"a":2,"b":3}`
+	wanted := map[DuplicateKeyPolicy]string{
+		DuplicateLastWins:  `{"b":3,"a":2}`,
+		DuplicateFirstWins: `{"b":1,"a":2}`,
+		DuplicateCollect:   `{"b":[1,3],"a":2}`,
+	}
+	for policy, w := range wanted {
+		obj, err := New([]byte(input), WithOrderedObjects(), WithDuplicateKeys(policy))
+		assert.NoError(t, err)
+		out, err := obj.MarshalJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, w, string(out))
+	}
+}
@@ -0,0 +1,147 @@
+package libjson
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelMinChunk is the minimum amount of bytes a goroutine parses, smaller
+// inputs are not worth the overhead of the pre-scan
+const parallelMinChunk = 256 * 1024
+
+// WithParallel makes New parse top level arrays on up to workers goroutines,
+// workers < 1 uses runtime.GOMAXPROCS(0). No more goroutines than
+// runtime.GOMAXPROCS(0) are used, the pre-scan only pays off if the chunks
+// are parsed at the same time. The input is split at element
+// boundaries found by a pre-scan and the parsed chunks are merged in order.
+// Invalid input is parsed again sequentially, so errors do not differ from
+// parsing without WithParallel. Other values, small arrays and JSON5 are
+// always parsed sequentially
+func WithParallel(workers int) Option {
+	return func(c *config) {
+		if workers < 1 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		c.workers = workers
+	}
+}
+
+// chunk is a range of data holding comma separated array elements
+type chunk struct {
+	start, end int
+}
+
+// splitArray splits the elements of the top level array in data into at
+// most workers chunks of similar size and counts the elements. Reports false
+// if data is not a single array or the chunks would be too small
+func splitArray(data []byte, workers int) ([]chunk, int, bool) {
+	size := max(len(data)/workers, parallelMinChunk)
+	chunks := make([]chunk, 0, workers)
+	start, depth, elements := -1, 0, 0
+	closed := false
+	for i := range tokenStarts(data) {
+		if closed {
+			// trailing data after the array
+			return nil, 0, false
+		}
+		switch data[i] {
+		case '[', '{':
+			if depth == 0 {
+				if data[i] != '[' {
+					return nil, 0, false
+				}
+				start = i + 1
+			}
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				if data[i] != ']' {
+					return nil, 0, false
+				}
+				chunks = append(chunks, chunk{start, i})
+				closed = true
+			}
+		case ',':
+			if depth == 1 {
+				elements++
+				if i-start >= size {
+					chunks = append(chunks, chunk{start, i})
+					start = i + 1
+				}
+			}
+		default:
+			if start == -1 {
+				return nil, 0, false
+			}
+		}
+	}
+	if !closed || len(chunks) < 2 {
+		return nil, 0, false
+	}
+	return chunks, elements + 1, true
+}
+
+// parseParallel parses the top level array in data concurrently, reports
+// false if data has to be parsed sequentially
+func parseParallel(data []byte, cfg config) (any, bool) {
+	workers := min(cfg.workers, runtime.GOMAXPROCS(0))
+	if cfg.json5 || workers < 2 || len(data) < 2*parallelMinChunk {
+		return nil, false
+	}
+	chunks, elements, ok := splitArray(data, workers)
+	if !ok || (cfg.maxArray > 0 && elements > cfg.maxArray) {
+		return nil, false
+	}
+
+	parts := make([][]any, len(chunks))
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// data is cut at the end of the chunk, so offsets stay the same
+			// and the lexer stops at the end of the chunk
+			p := parser{l: lexer{data: data[:c.end], pos: c.start, cfg: cfg}, depth: 1}
+			a, err := p.elements()
+			if err != nil {
+				failed.Store(true)
+				return
+			}
+			parts[i] = a
+		}()
+	}
+	wg.Wait()
+	if failed.Load() {
+		return nil, false
+	}
+
+	a := make([]any, 0, elements)
+	for _, part := range parts {
+		a = append(a, part...)
+	}
+	return a, true
+}
+
+// elements parses comma separated values up to the end of the input
+func (p *parser) elements() ([]any, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	a := make([]any, 0, 8)
+	for {
+		v, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+		if p.t.Type == t_eof {
+			return a, nil
+		}
+		if err := p.expect(t_comma); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package libjson
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// largeArray returns a top level array spanning several parallel chunks, with
+// structural characters in strings and nested containers
+func largeArray() []byte {
+	var b bytes.Buffer
+	b.WriteString("[\n")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			b.WriteString(",\n")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "text": "a, [b] {c} \"d\", e\\", "tags": [[], {}, [1, {"x": null}]], "n": -1.5e3}`, i)
+	}
+	b.WriteString("\n]\n")
+	return b.Bytes()
+}
+
+func TestSplitArray(t *testing.T) {
+	data := largeArray()
+	chunks, elements, ok := splitArray(data, 4)
+	assert.True(t, ok)
+	assert.Equal(t, 20000, elements)
+	assert.Len(t, chunks, 4)
+	assert.Equal(t, 1, chunks[0].start)
+	for i := 1; i < len(chunks); i++ {
+		assert.Equal(t, byte(','), data[chunks[i].start-1])
+		assert.Equal(t, chunks[i-1].end+1, chunks[i].start)
+	}
+	assert.Equal(t, byte(']'), data[chunks[3].end])
+
+	for _, in := range []string{"{}", `"str"`, "[1, 2]", "[1] [2]", "1"} {
+		_, _, ok := splitArray([]byte(in), 4)
+		assert.False(t, ok, in)
+	}
+}
+
+// procs makes sure at least n goroutines run in parallel until the test ends,
+// WithParallel parses sequentially otherwise
+func procs(t *testing.T, n int) {
+	prev := runtime.GOMAXPROCS(max(n, runtime.GOMAXPROCS(0)))
+	t.Cleanup(func() { runtime.GOMAXPROCS(prev) })
+}
+
+func TestParallel(t *testing.T) {
+	procs(t, 4)
+	data := largeArray()
+	expected, err := New(data)
+	assert.NoError(t, err)
+	obj, err := New(data, WithParallel(4))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, obj)
+	_, ok := parseParallel(data, newConfig([]Option{WithParallel(4)}))
+	assert.True(t, ok)
+
+	obj, err = New(data, WithParallel(4), WithOrderedObjects(), WithNumberMode(NumberText))
+	assert.NoError(t, err)
+	v, err := Get[Number](obj, ".19999.n")
+	assert.NoError(t, err)
+	assert.Equal(t, Number("-1.5e3"), v)
+
+	// a single core parses sequentially
+	runtime.GOMAXPROCS(1)
+	_, ok = parseParallel(data, newConfig([]Option{WithParallel(4)}))
+	assert.False(t, ok)
+}
+
+func TestParallelErrors(t *testing.T) {
+	procs(t, 4)
+	data := largeArray()
+	mid := bytes.Index(data[len(data)/2:], []byte("null")) + len(data)/2
+	inputs := map[string][]byte{
+		"invalid atom":   append(append(bytes.Clone(data[:mid]), "nul "...), data[mid+4:]...),
+		"trailing comma": append(bytes.Clone(data[:len(data)-3]), ",]"...),
+		"mismatched":     append(bytes.Clone(data[:len(data)-2]), '}'),
+		"trailing data":  append(bytes.Clone(data), "[]"...),
+		"unterminated":   data[:len(data)-2],
+	}
+	for name, in := range inputs {
+		t.Run(name, func(t *testing.T) {
+			_, expected := New(in)
+			assert.Error(t, expected)
+			_, err := New(in, WithParallel(4))
+			assert.Equal(t, expected, err)
+		})
+	}
+
+	duplicate := bytes.Replace(data, []byte(`"n"`), []byte(`"id"`), 1)
+	limits := []struct {
+		in  []byte
+		opt Option
+	}{
+		{data, WithMaxArrayLength(100)},
+		{data, WithMaxDepth(3)},
+		{duplicate, WithDuplicateKeys(DuplicateError)},
+	}
+	for _, l := range limits {
+		_, expected := New(l.in, l.opt)
+		assert.Error(t, expected)
+		_, err := New(l.in, l.opt, WithParallel(4))
+		assert.Equal(t, expected, err)
+	}
+}
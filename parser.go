@@ -1,14 +1,57 @@
 package libjson
 
 import (
-	"fmt"
+	"math/big"
 	"strconv"
 	"unsafe"
 )
 
 type parser struct {
-	l lexer
-	t token
+	l     lexer
+	t     token
+	depth int  // current nesting of objects and arrays
+	hold  bool // set by Decoder, the token following a top level value is not read
+
+	// buffers kept between documents by Parser
+	stack   []any  // elements of the arrays currently parsed
+	window  []byte // read buffer of a streaming lexer
+	scratch []byte // escaped strings decoded by Valid
+	arena   *arena // only set for WithArena, handed to the resulting JSON
+}
+
+// reset prepares p for parsing the input of l, keeping its buffers
+func (p *parser) reset(l lexer) {
+	p.l = l
+	p.t = token{}
+	p.depth = 0
+	if l.cfg.arena {
+		p.arena = arenas.Get().(*arena)
+	}
+}
+
+// abort drops the state of a failed parse, so p can be reused
+func (p *parser) abort(err error) error {
+	clear(p.stack)
+	p.stack = p.stack[:0]
+	if p.arena != nil {
+		p.arena.release()
+		p.arena = nil
+	}
+	return err
+}
+
+// enter tracks the nesting depth for cfg.maxDepth, must be called before
+// consuming the opening token of an object or an array, leave undoes it
+func (p *parser) enter() error {
+	p.depth++
+	if p.l.cfg.maxDepth > 0 && p.depth > p.l.cfg.maxDepth {
+		return p.l.limitError(ErrMaxDepth, p.l.cfg.maxDepth, p.l.start)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
 }
 
 func (p *parser) advance() error {
@@ -22,11 +65,61 @@ func (p *parser) advance() error {
 
 func (p *parser) expect(t t_json) error {
 	if p.t.Type != t {
-		return fmt.Errorf("Unexpected %q at this position, expected %q", tokennames[p.t.Type], tokennames[t])
+		return p.l.errorf(p.l.start, "Unexpected %q at this position, expected %q", tokennames[p.t.Type], tokennames[t])
 	}
 	return p.advance()
 }
 
+// close consumes t, the closing token of the object or array p is in. With
+// hold set, the token following a top level value is not read
+func (p *parser) close(t t_json) error {
+	if p.hold && p.depth == 1 && p.t.Type == t {
+		return nil
+	}
+	return p.expect(t)
+}
+
+// keyOffset is the offset of the current token in the input. Unlike l.start
+// it stays valid once a streaming lexer moved its window, unless the token
+// was discarded from the window, errors then point at the start of the window
+func (p *parser) keyOffset() int {
+	return p.l.offset + p.l.start
+}
+
+// key consumes the current object key, JSON5 allows identifiers as keys
+func (p *parser) key() (string, error) {
+	key := p.str()
+	return key, p.skipKey()
+}
+
+// skipKey consumes the current object key without converting it
+func (p *parser) skipKey() error {
+	if p.l.cfg.json5 && identKey(p.t) {
+		return p.advance()
+	}
+	return p.expect(t_string)
+}
+
+// trailingComma reports whether the comma just consumed was followed by
+// closing, which is only allowed in JSON5 mode
+func (p *parser) trailingComma(closing t_json) bool {
+	return p.l.cfg.json5 && p.t.Type == closing
+}
+
+// unexpectedValue is the error for a token not starting a value
+func (p *parser) unexpectedValue() error {
+	return p.l.errorf(p.l.start, "Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[p.t.Type])
+}
+
+// str returns the value of the current token as a string, it aliases the
+// input unless the lexer is streaming and reuses its window
+func (p *parser) str() string {
+	if p.l.r != nil {
+		return string(p.t.Val)
+	}
+	return *(*string)(unsafe.Pointer(&p.t.Val))
+}
+
 // parses toks into a valid json representation, thus the return type can be
 // either map[string]any, []any, string, nil, false, true or a number
 func (p *parser) parse() (any, error) {
@@ -38,7 +131,7 @@ func (p *parser) parse() (any, error) {
 		return nil, err
 	} else {
 		if p.t.Type != t_eof {
-			return nil, fmt.Errorf("Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
+			return nil, p.l.errorf(p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
 		}
 		return val, nil
 	}
@@ -54,35 +147,132 @@ func (p *parser) expression() (any, error) {
 	}
 }
 
-func (p *parser) object() (map[string]any, error) {
+// members abstracts over the two object representations, only one of m and o
+// is set
+type members struct {
+	m map[string]any
+	o *Object
+	// keys whose values were already collected into an array by
+	// DuplicateCollect, to distinguish them from keys with array values
+	collected map[string]struct{}
+}
+
+func (p *parser) newMembers() members {
+	if p.l.cfg.ordered {
+		return members{o: NewObject(8)}
+	} else if p.arena != nil {
+		return members{m: p.arena.object()}
+	}
+	return members{m: make(map[string]any, 8)}
+}
+
+func (ms members) get(key string) (any, bool) {
+	if ms.o != nil {
+		return ms.o.Get(key)
+	}
+	v, ok := ms.m[key]
+	return v, ok
+}
+
+func (ms members) set(key string, val any) {
+	if ms.o != nil {
+		ms.o.Set(key, val)
+	} else {
+		ms.m[key] = val
+	}
+}
+
+// duplicate returns the error for key if DuplicateError forbids adding it to
+// ms. It is checked before parsing the value, like validateObject does,
+// keyStart is the offset of the key in the input, see keyOffset
+func (p *parser) duplicate(ms *members, key string, keyStart int) error {
+	if p.l.cfg.duplicates != DuplicateError {
+		return nil
+	}
+	if _, ok := ms.get(key); ok {
+		return p.l.errorf(keyStart-p.l.offset, "Duplicate key %q in object", key)
+	}
+	return nil
+}
+
+// addMember adds key to ms according to p.l.cfg.duplicates, duplicates
+// rejected by DuplicateError are dropped
+func (p *parser) addMember(ms *members, key string, val any) {
+	// the default is checked first, so the hot path only pays for a branch
+	// and not for hashing key an other time
+	if p.l.cfg.duplicates == DuplicateLastWins {
+		ms.set(key, val)
+		return
+	}
+	old, ok := ms.get(key)
+	if !ok {
+		ms.set(key, val)
+		return
+	}
+	switch p.l.cfg.duplicates {
+	case DuplicateCollect:
+		if ms.collected == nil {
+			ms.collected = make(map[string]struct{}, 1)
+		}
+		if _, ok := ms.collected[key]; ok {
+			ms.set(key, append(old.([]any), val))
+		} else {
+			ms.collected[key] = struct{}{}
+			ms.set(key, []any{old, val})
+		}
+	}
+}
+
+func (ms members) value() any {
+	if ms.o != nil {
+		return ms.o
+	}
+	return ms.m
+}
+
+// object returns either a map[string]any or an *Object, depending on
+// p.l.cfg.ordered
+func (p *parser) object() (any, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
 	err := p.expect(t_left_curly)
 	if err != nil {
 		return nil, err
 	}
 
-	m := make(map[string]any, 8)
+	m := p.newMembers()
 
 	if p.t.Type == t_right_curly {
-		err = p.advance()
-		if err != nil {
-			return nil, err
-		}
-		return m, nil
+		return m.value(), p.close(t_right_curly)
 	}
 
+	// count includes duplicate keys, the length of m does not
+	count := 0
 	for p.t.Type != t_eof && p.t.Type != t_right_curly {
-		if len(m) > 0 {
+		if count > 0 {
 			err := p.expect(t_comma)
 			if err != nil {
 				return nil, err
 			}
+			if p.trailingComma(t_right_curly) {
+				break
+			}
+			if p.l.cfg.maxMembers > 0 && count >= p.l.cfg.maxMembers {
+				return nil, p.l.limitError(ErrMaxObjectMembers, p.l.cfg.maxMembers, p.l.start)
+			}
 		}
 
-		key := *(*string)(unsafe.Pointer(&p.t.Val))
-		err := p.expect(t_string)
+		keyStart := p.keyOffset()
+		key, err := p.key()
 		if err != nil {
 			return nil, err
 		}
+		if err := p.duplicate(&m, key, keyStart); err != nil {
+			return nil, err
+		}
 
 		err = p.expect(t_colon)
 		if err != nil {
@@ -94,63 +284,77 @@ func (p *parser) object() (map[string]any, error) {
 			return nil, err
 		}
 
-		// TODO:  think about activating a uniqueness check for object keys,
-		// would add an other hashing and a branch for each object key parsed.
-		//
-		// if _, ok := m[key]; ok {
-		// 	return nil, fmt.Errorf("Key %q is already set in this object", key)
-		// }
-
-		m[key] = val
+		count++
+		p.addMember(&m, key, val)
 	}
 
-	err = p.expect(t_right_curly)
+	err = p.close(t_right_curly)
 	if err != nil {
 		return nil, err
 	}
 
-	return m, nil
+	return m.value(), nil
 }
 
 func (p *parser) array() ([]any, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
 	err := p.expect(t_left_braket)
 	if err != nil {
 		return nil, err
 	}
 
 	if p.t.Type == t_right_braket {
-		err = p.advance()
-		return []any{}, err
+		return []any{}, p.close(t_right_braket)
 	}
 
-	a := make([]any, 0, 8)
-
+	// elements are collected on p.stack, so the array can be allocated with
+	// its final length
+	base := len(p.stack)
 	for p.t.Type != t_eof && p.t.Type != t_right_braket {
-		if len(a) > 0 {
+		if len(p.stack) > base {
 			err := p.expect(t_comma)
 			if err != nil {
 				return nil, err
 			}
+			if p.trailingComma(t_right_braket) {
+				break
+			}
+			if p.l.cfg.maxArray > 0 && len(p.stack)-base >= p.l.cfg.maxArray {
+				return nil, p.l.limitError(ErrMaxArrayLength, p.l.cfg.maxArray, p.l.start)
+			}
 		}
 		node, err := p.expression()
 		if err != nil {
 			return nil, err
 		}
-		a = append(a, node)
+		p.stack = append(p.stack, node)
 	}
 
-	return a, p.expect(t_right_braket)
+	var a []any
+	if p.arena != nil {
+		a = p.arena.slice(len(p.stack) - base)
+	} else {
+		a = make([]any, len(p.stack)-base)
+	}
+	copy(a, p.stack[base:])
+	clear(p.stack[base:])
+	p.stack = p.stack[:base]
+	return a, p.close(t_right_braket)
 }
 
 func (p *parser) atom() (any, error) {
 	var r any
 	switch p.t.Type {
 	case t_string:
-		r = *(*string)(unsafe.Pointer(&p.t.Val))
+		r = p.str()
 	case t_number:
-		number, err := strconv.ParseFloat(*(*string)(unsafe.Pointer(&p.t.Val)), 64)
+		number, err := p.number()
 		if err != nil {
-			return empty, fmt.Errorf("Invalid floating point number %q: %w", p.t.Val, err)
+			return nil, err
 		}
 		r = number
 	case t_true:
@@ -160,10 +364,182 @@ func (p *parser) atom() (any, error) {
 	case t_null:
 		r = nil
 	default:
-		return nil, fmt.Errorf("Unexpected %q at this position, expected any of: string, number, true, false or null", tokennames[p.t.Type])
+		return nil, p.unexpectedValue()
+	}
+	if p.hold && p.depth == 0 {
+		return r, nil
 	}
 	if err := p.advance(); err != nil {
 		return nil, err
 	}
 	return r, nil
 }
+
+// number converts the current t_number token according to p.l.cfg.numbers
+func (p *parser) number() (any, error) {
+	raw := *(*string)(unsafe.Pointer(&p.t.Val))
+	if p.l.cfg.json5 {
+		if isHex(raw) {
+			return p.hexNumber(raw)
+		}
+		// strconv only accepts NaN without a sign
+		if raw[len(raw)-1] == 'N' {
+			raw = "NaN"
+		}
+	}
+	switch p.l.cfg.numbers {
+	case NumberText:
+		return Number(p.str()), nil
+	case NumberBig:
+		if isInteger(p.t.Val) {
+			if i, ok := new(big.Int).SetString(raw, 10); ok {
+				return i, nil
+			}
+		}
+		// formatting a *big.Float takes time growing with its exponent, so
+		// the exact text is kept and converted on demand instead
+		return Number(p.str()), nil
+	case NumberInteger:
+		if isInteger(p.t.Val) {
+			if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return i, nil
+			} else if raw[0] != '-' {
+				if u, err := strconv.ParseUint(raw, 10, 64); err == nil {
+					return u, nil
+				}
+			}
+		}
+	}
+	number, err := p.float(raw)
+	if err != nil {
+		return nil, err
+	}
+	return number, nil
+}
+
+// float converts raw, the text of the current number token, to a float64
+func (p *parser) float(raw string) (float64, error) {
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		// the NumError quotes raw again
+		if nerr, ok := err.(*strconv.NumError); ok {
+			err = nerr.Err
+		}
+		return 0, p.l.errorf(p.l.start, "Invalid floating point number %s: %s", quoteExcerpt(p.t.Val), err)
+	}
+	return number, nil
+}
+
+// validate checks the value starting at the current token and advances past
+// it without building it. Unlike skip, numbers and DuplicateError are checked
+// too, so validate fails exactly where parsing the value would
+func (p *parser) validate() error {
+	switch p.t.Type {
+	case t_left_curly:
+		return p.validateObject()
+	case t_left_braket:
+		return p.validateArray()
+	case t_number:
+		if err := p.checkNumber(); err != nil {
+			return err
+		}
+	case t_string, t_true, t_false, t_null:
+	default:
+		return p.unexpectedValue()
+	}
+	return p.advance()
+}
+
+// checkNumber fails for the current number token if converting it according
+// to the number mode would
+func (p *parser) checkNumber() error {
+	if p.l.cfg.numbers == NumberFloat64 && !p.l.cfg.json5 {
+		_, err := p.float(*(*string)(unsafe.Pointer(&p.t.Val)))
+		return err
+	}
+	_, err := p.number()
+	return err
+}
+
+func (p *parser) validateObject() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+	if err := p.expect(t_left_curly); err != nil {
+		return err
+	}
+
+	count := 0
+	var seen map[string]struct{} // only used for DuplicateError
+	for p.t.Type != t_eof && p.t.Type != t_right_curly {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_curly) {
+				break
+			}
+			if p.l.cfg.maxMembers > 0 && count >= p.l.cfg.maxMembers {
+				return p.l.limitError(ErrMaxObjectMembers, p.l.cfg.maxMembers, p.l.start)
+			}
+		}
+
+		if p.l.cfg.duplicates != DuplicateError {
+			if err := p.skipKey(); err != nil {
+				return err
+			}
+		} else {
+			keyStart := p.keyOffset()
+			key, err := p.key()
+			if err != nil {
+				return err
+			}
+			if seen == nil {
+				seen = make(map[string]struct{}, 8)
+			}
+			if _, ok := seen[key]; ok {
+				return p.l.errorf(keyStart-p.l.offset, "Duplicate key %q in object", key)
+			}
+			seen[key] = struct{}{}
+		}
+		if err := p.expect(t_colon); err != nil {
+			return err
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+		count++
+	}
+	return p.expect(t_right_curly)
+}
+
+func (p *parser) validateArray() error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+	if err := p.expect(t_left_braket); err != nil {
+		return err
+	}
+
+	count := 0
+	for p.t.Type != t_eof && p.t.Type != t_right_braket {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_braket) {
+				break
+			}
+			if p.l.cfg.maxArray > 0 && count >= p.l.cfg.maxArray {
+				return p.l.limitError(ErrMaxArrayLength, p.l.cfg.maxArray, p.l.start)
+			}
+		}
+		if err := p.validate(); err != nil {
+			return err
+		}
+		count++
+	}
+	return p.expect(t_right_braket)
+}
@@ -2,6 +2,7 @@ package libjson
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,7 @@ func TestParserAtoms(t *testing.T) {
 		"null",
 		"12345",
 		`"isastring"`,
+		`"say \"hi\"\n"`,
 	}
 	wanted := []any{
 		// i forgor 💀
@@ -25,6 +27,7 @@ func TestParserAtoms(t *testing.T) {
 		nil,
 		12345.0,
 		"isastring",
+		"say \"hi\"\n",
 	}
 	for i, in := range input {
 		t.Run(in, func(t *testing.T) {
@@ -138,6 +141,10 @@ func TestParserFail(t *testing.T) {
 		"1.0e+",
 		"0E",
 		"1eE2",
+		"01",
+		"1.e5",
+		"[1-2]",
+		"--1",
 	}
 	for _, in := range input {
 		t.Run(in, func(t *testing.T) {
@@ -148,3 +155,41 @@ func TestParserFail(t *testing.T) {
 		})
 	}
 }
+
+func TestParserDuplicateKeys(t *testing.T) {
+	input := `{"a": 1, "b": [0], "a": 2, "b": [1], "a": 3}`
+	wanted := map[DuplicateKeyPolicy]any{
+		DuplicateLastWins:  map[string]any{"a": 3.0, "b": []any{1.0}},
+		DuplicateFirstWins: map[string]any{"a": 1.0, "b": []any{0.0}},
+		DuplicateCollect:   map[string]any{"a": []any{1.0, 2.0, 3.0}, "b": []any{[]any{0.0}, []any{1.0}}},
+	}
+	for policy, w := range wanted {
+		p := &parser{l: lexer{data: []byte(input), cfg: config{duplicates: policy}}}
+		out, err := p.parse()
+		assert.NoError(t, err)
+		assert.EqualValues(t, w, out)
+	}
+}
+
+func TestParserDuplicateKeysError(t *testing.T) {
+	_, err := New([]byte("{\n  \"a\": 1,\n  \"a\": 2\n}"), WithDuplicateKeys(DuplicateError))
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Contains(t, serr.Msg, `"a"`)
+		assert.Equal(t, 3, serr.Line)
+		assert.Equal(t, 3, serr.Column)
+	}
+
+	_, err = New([]byte(`{"a": {"a": 1}, "b": [{"a": 1}, {"a": 2}]}`), WithDuplicateKeys(DuplicateError))
+	assert.NoError(t, err)
+}
+
+func TestParserFloatError(t *testing.T) {
+	_, err := New([]byte(`[1e400]`))
+	assert.ErrorContains(t, err, `Invalid floating point number "1e400": value out of range`)
+
+	in := "9" + strings.Repeat("0", 1<<20) + "e400"
+	_, err = NewReader(strings.NewReader(in))
+	assert.ErrorContains(t, err, `Invalid floating point number "9`+strings.Repeat("0", snippetWidth-1)+`"...: value out of range`)
+	assert.Less(t, len(err.Error()), 256)
+}
@@ -0,0 +1,118 @@
+package libjson
+
+import (
+	"errors"
+	"io"
+	"iter"
+)
+
+// ErrFinished is returned by Feed and Finish once Finish was called
+var ErrFinished = errors.New("Push parser already finished")
+
+// PushParser parses a stream of concatenated JSON values, like Decoder, but
+// is fed the input in chunks of any size instead of reading it. Chunks may
+// split the input anywhere, even inside of strings, numbers and true, false
+// or null.
+//
+// The values are decoded by a Decoder running as a coroutine, see iter.Pull,
+// reading from the fed chunks. Once a chunk is consumed the Decoder is
+// suspended in the middle of its current token, so the lexer and parser
+// resume exactly where they stopped when the next chunk arrives and every
+// byte is scanned once. Like for a Decoder, only the token currently lexed
+// is buffered
+type PushParser struct {
+	d     *Decoder
+	emit  func(*JSON) error
+	err   error
+	chunk []byte // input fed, but not yet read by the lexer
+	eof   bool   // set by Finish
+	done  bool   // Finish returned
+
+	next  func() (struct{}, bool)
+	stop  func()
+	yield func(struct{}) bool // suspends run until the next call to next
+}
+
+// NewPushParser returns a PushParser calling emit with every value as soon as
+// its last byte was fed, for numbers this is the byte following them or the
+// call to Finish. An error returned by emit stops the parser and is returned
+// by Feed. The options apply to every value, like for NewDecoder. Finish has
+// to be called once the input ends, even after an error, to release the
+// suspended Decoder
+func NewPushParser(emit func(*JSON) error, opts ...Option) *PushParser {
+	pp := &PushParser{emit: emit}
+	pp.d = NewDecoder(pushReader{pp}, opts...)
+	pp.next, pp.stop = iter.Pull(pp.run)
+	return pp
+}
+
+// run decodes and emits values until the input ends or an error occurs, the
+// Decoder is suspended whenever it needs more input than was fed
+func (pp *PushParser) run(yield func(struct{}) bool) {
+	pp.yield = yield
+	for {
+		j, err := pp.d.Decode()
+		if err == io.EOF {
+			return
+		} else if err == nil {
+			err = pp.emit(j)
+		}
+		if err != nil {
+			pp.err = err
+			return
+		}
+	}
+}
+
+// pushReader hands the fed chunks to the lexer of the Decoder
+type pushReader struct {
+	pp *PushParser
+}
+
+func (r pushReader) Read(b []byte) (int, error) {
+	pp := r.pp
+	for len(pp.chunk) == 0 {
+		if pp.eof {
+			return 0, io.EOF
+		}
+		// wait for Feed or Finish, false if the coroutine was stopped
+		if !pp.yield(struct{}{}) {
+			return 0, ErrFinished
+		}
+	}
+	n := copy(b, pp.chunk)
+	pp.chunk = pp.chunk[n:]
+	return n, nil
+}
+
+// Feed parses chunk, emitting all values it completes. chunk is copied, it
+// can be reused once Feed returns. Errors are sticky until Finish
+func (pp *PushParser) Feed(chunk []byte) error {
+	if pp.done {
+		return ErrFinished
+	}
+	if pp.err != nil {
+		return pp.err
+	}
+	pp.chunk = chunk
+	// returns once the lexer read all of chunk and waits for more
+	pp.next()
+	pp.chunk = nil
+	return pp.err
+}
+
+// Finish signals the end of the input, a pending number is emitted and an
+// incomplete value is reported as an error
+func (pp *PushParser) Finish() error {
+	if pp.done {
+		return ErrFinished
+	}
+	pp.done = true
+	if pp.err == nil {
+		pp.eof = true
+		// returns once the Decoder reached the end of the input or failed
+		pp.next()
+	}
+	pp.stop()
+	return pp.err
+}
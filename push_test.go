@@ -0,0 +1,167 @@
+package libjson
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeAll returns all values of input decoded by a Decoder and the error
+// ending the stream
+func decodeAll(input string, opts ...Option) ([]any, error) {
+	d := NewDecoder(strings.NewReader(input), opts...)
+	values := []any{}
+	for {
+		j, err := d.Decode()
+		if err == io.EOF {
+			return values, nil
+		} else if err != nil {
+			return values, err
+		}
+		values = append(values, j.obj)
+	}
+}
+
+// pushAll feeds input split at splits to a PushParser
+func pushAll(input string, splits []int, opts ...Option) ([]any, error) {
+	values := []any{}
+	pp := NewPushParser(func(j *JSON) error {
+		values = append(values, j.obj)
+		return nil
+	}, opts...)
+	last := 0
+	for _, s := range append(splits, len(input)) {
+		if err := pp.Feed([]byte(input[last:s])); err != nil {
+			return values, err
+		}
+		last = s
+	}
+	return values, pp.Finish()
+}
+
+func TestPushParser(t *testing.T) {
+	inputs := []struct {
+		input string
+		opts  []Option
+	}{
+		{`{"a":1}{"b":2}[3]"str"true null 1 2.5` + "\n\t" + `[]`, nil},
+		{`{"a": ["}", "\"]", {"b": "\\"}], "c": "ä🤣"} "{[" -1.5e+3 false`, nil},
+		{`{"nested": [[[{"x": [1, 2, {"y": null}]}]]]}` + "\n" + `"x"`, nil},
+		{`[1, 2, 3]
+		{"a": 1}
+		[1,]
+		{}`, nil},
+		{`{"a": 1} ]`, nil},
+		{`{"a": "unterminated`, nil},
+		{`tru`, nil},
+		{`{"a": 1, "a": 2}`, []Option{WithDuplicateKeys(DuplicateError)}},
+		{`truefalse nulltrue"x"1true[]-0null`, nil},
+		{`// comment
+		{a: 'b}', /* } */ c: [0x10, Infinity,],} /**/ 'x' // tail`, []Option{WithJSON5()}},
+		{`1 /* c */2 /x`, []Option{WithJSON5()}},
+		{`1 /* c */2 /* open`, []Option{WithJSON5()}},
+	}
+
+	for _, in := range inputs {
+		expected, expectedErr := decodeAll(in.input, in.opts...)
+		for size := 1; size <= len(in.input); size++ {
+			var splits []int
+			for s := size; s < len(in.input); s += size {
+				splits = append(splits, s)
+			}
+			values, err := pushAll(in.input, splits, in.opts...)
+			assert.Equal(t, expected, values, "%q in chunks of %d", in.input, size)
+			assert.Equal(t, expectedErr == nil, err == nil, "%q in chunks of %d: %v", in.input, size, err)
+		}
+	}
+}
+
+func TestPushParserRandomSplits(t *testing.T) {
+	input := `{"key": "value with \"escapes\" and \\", "list": [1, -2.5e10, true, false, null]} 123 "str" [[], {}]`
+	expected, err := decodeAll(input)
+	assert.NoError(t, err)
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 200 {
+		var splits []int
+		for s := rng.IntN(8); s < len(input); s += 1 + rng.IntN(8) {
+			splits = append(splits, s)
+		}
+		values, err := pushAll(input, splits)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, values, "%v", splits)
+	}
+}
+
+func TestPushParserEmitsEarly(t *testing.T) {
+	var values []any
+	pp := NewPushParser(func(j *JSON) error {
+		values = append(values, j.obj)
+		return nil
+	})
+	assert.NoError(t, pp.Feed([]byte(`{"a": [1`)))
+	assert.Empty(t, values)
+	assert.NoError(t, pp.Feed([]byte(`]}`)))
+	assert.Len(t, values, 1)
+	assert.NoError(t, pp.Feed([]byte(` 12`)))
+	assert.Len(t, values, 1)
+	assert.NoError(t, pp.Feed([]byte(`3`)))
+	assert.Len(t, values, 1)
+	assert.NoError(t, pp.Finish())
+	assert.Equal(t, []any{map[string]any{"a": []any{1.0}}, 123.0}, values)
+	assert.ErrorIs(t, pp.Feed([]byte(`1`)), ErrFinished)
+	assert.ErrorIs(t, pp.Finish(), ErrFinished)
+}
+
+func TestPushParserErrors(t *testing.T) {
+	pp := NewPushParser(func(*JSON) error { return nil })
+	assert.NoError(t, pp.Feed([]byte("{}\n[1")))
+	err := pp.Feed([]byte(",]\n{}"))
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		_, expected := decodeAll("{}\n[1,]\n{}")
+		assert.Equal(t, expected.(*SyntaxError).Offset, serr.Offset)
+		assert.Equal(t, expected.(*SyntaxError).Line, serr.Line)
+		assert.Equal(t, expected.(*SyntaxError).Column, serr.Column)
+	}
+	// errors are sticky until Finish
+	assert.Equal(t, err, pp.Feed([]byte(`{}`)))
+	assert.Equal(t, err, pp.Finish())
+	assert.ErrorIs(t, pp.Feed([]byte(`{}`)), ErrFinished)
+	assert.ErrorIs(t, pp.Finish(), ErrFinished)
+
+	pp = NewPushParser(func(*JSON) error { return nil })
+	assert.NoError(t, pp.Feed([]byte(`[1, 2`)))
+	assert.Error(t, pp.Finish())
+	assert.ErrorIs(t, pp.Feed([]byte(`]`)), ErrFinished)
+	assert.ErrorIs(t, pp.Finish(), ErrFinished)
+
+	// the limit applies to every value and the whitespace preceding it
+	pp = NewPushParser(func(*JSON) error { return nil }, WithMaxBytes(8))
+	assert.NoError(t, pp.Feed([]byte(`[1, 2] [1, `)))
+	err = pp.Feed([]byte(`2, 3, 4]`))
+	var lerr *LimitError
+	if assert.ErrorAs(t, err, &lerr) {
+		assert.Equal(t, 6+8, lerr.Offset)
+	}
+	assert.Equal(t, err, pp.Finish())
+
+	// comments are limited as well
+	pp = NewPushParser(func(*JSON) error { return nil }, WithMaxBytes(64), WithJSON5())
+	assert.NoError(t, pp.Feed([]byte(`1 /* open`)))
+	for range 8 {
+		if err = pp.Feed([]byte(strings.Repeat("x", 16))); err != nil {
+			break
+		}
+	}
+	assert.ErrorAs(t, err, &lerr)
+	assert.Equal(t, err, pp.Finish())
+
+	stop := errors.New("stop")
+	pp = NewPushParser(func(*JSON) error { return stop })
+	assert.ErrorIs(t, pp.Feed([]byte(`1 2 3`)), stop)
+	assert.ErrorIs(t, pp.Finish(), stop)
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package libjson
+
+// race reports whether the race detector is enabled, it makes sync.Pool drop
+// items at random
+const race = true
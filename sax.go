@@ -0,0 +1,271 @@
+package libjson
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrSkip can be returned from Handler.OnObjectStart, Handler.OnArrayStart
+// and Handler.OnKey to skip the object, the array or the value of the key.
+// Skipped input is still checked like by Valid, but produces no events
+var ErrSkip = errors.New("Skip this value")
+
+// Handler receives the events of Walk and WalkReader in document order.
+// Returning ErrSkip skips the current subtree where documented, every other
+// error aborts the walk and is returned as is. Embed NopHandler to only
+// implement the events of interest
+type Handler interface {
+	OnObjectStart() error
+	OnKey(key string) error
+	OnObjectEnd() error
+	OnArrayStart() error
+	OnArrayEnd() error
+	OnString(s string) error
+	// OnNumber receives numbers in their textual representation, independent
+	// of WithNumberMode, use the accessors of Number for conversion
+	OnNumber(n Number) error
+	OnBool(b bool) error
+	OnNull() error
+}
+
+// NopHandler implements Handler by ignoring every event
+type NopHandler struct{}
+
+func (NopHandler) OnObjectStart() error    { return nil }
+func (NopHandler) OnKey(key string) error  { return nil }
+func (NopHandler) OnObjectEnd() error      { return nil }
+func (NopHandler) OnArrayStart() error     { return nil }
+func (NopHandler) OnArrayEnd() error       { return nil }
+func (NopHandler) OnString(s string) error { return nil }
+func (NopHandler) OnNumber(n Number) error { return nil }
+func (NopHandler) OnBool(b bool) error     { return nil }
+func (NopHandler) OnNull() error           { return nil }
+
+// Walk parses data and calls h for every value, without ever building
+// map[string]any or []any values. Strings and numbers passed to h alias data.
+// Walk fails for exactly the inputs New fails for with the same options,
+// including duplicate keys rejected by DuplicateError and numbers the number
+// mode can not represent
+func Walk(data []byte, h Handler, opts ...Option) error {
+	p := parser{l: lexer{data: data, cfg: newConfig(opts)}}
+	return p.walk(h)
+}
+
+// WalkReader is Walk for input read from r through a bounded window, memory
+// usage only depends on the size of the largest token and not on the size of
+// the input
+func WalkReader(r io.Reader, h Handler, opts ...Option) error {
+	p := parser{l: lexer{r: r, cfg: newConfig(opts)}}
+	return p.walk(h)
+}
+
+func (p *parser) walk(h Handler) error {
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.walkValue(h); err != nil {
+		return err
+	}
+	if p.t.Type != t_eof {
+		return p.l.errorf(p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
+	}
+	return nil
+}
+
+func (p *parser) walkValue(h Handler) error {
+	var err error
+	switch p.t.Type {
+	case t_left_curly:
+		return p.walkObject(h)
+	case t_left_braket:
+		return p.walkArray(h)
+	case t_string:
+		err = h.OnString(p.str())
+	case t_number:
+		if err := p.checkNumber(); err != nil {
+			return err
+		}
+		err = h.OnNumber(Number(p.str()))
+	case t_true:
+		err = h.OnBool(true)
+	case t_false:
+		err = h.OnBool(false)
+	case t_null:
+		err = h.OnNull()
+	default:
+		return p.unexpectedValue()
+	}
+	if err != nil {
+		return err
+	}
+	return p.advance()
+}
+
+func (p *parser) walkObject(h Handler) error {
+	if err := h.OnObjectStart(); err == ErrSkip {
+		return p.validate()
+	} else if err != nil {
+		return err
+	}
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	if err := p.expect(t_left_curly); err != nil {
+		return err
+	}
+
+	count := 0
+	var seen map[string]struct{} // only used for DuplicateError
+	for p.t.Type != t_eof && p.t.Type != t_right_curly {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_curly) {
+				break
+			}
+			if p.l.cfg.maxMembers > 0 && count >= p.l.cfg.maxMembers {
+				return p.l.limitError(ErrMaxObjectMembers, p.l.cfg.maxMembers, p.l.start)
+			}
+		}
+
+		if p.t.Type != t_string && !(p.l.cfg.json5 && identKey(p.t)) {
+			return p.expect(t_string)
+		}
+		key := p.str()
+		if p.l.cfg.duplicates == DuplicateError {
+			if seen == nil {
+				seen = make(map[string]struct{}, 8)
+			}
+			if _, ok := seen[key]; ok {
+				return p.l.errorf(p.l.start, "Duplicate key %q in object", key)
+			}
+			seen[key] = struct{}{}
+		}
+		keyErr := h.OnKey(key)
+		if keyErr != nil && keyErr != ErrSkip {
+			return keyErr
+		}
+		if err := p.advance(); err != nil {
+			return err
+		}
+		if err := p.expect(t_colon); err != nil {
+			return err
+		}
+
+		var err error
+		if keyErr == ErrSkip {
+			err = p.validate()
+		} else {
+			err = p.walkValue(h)
+		}
+		if err != nil {
+			return err
+		}
+		count++
+	}
+
+	if err := p.expect(t_right_curly); err != nil {
+		return err
+	}
+	return h.OnObjectEnd()
+}
+
+func (p *parser) walkArray(h Handler) error {
+	if err := h.OnArrayStart(); err == ErrSkip {
+		return p.validate()
+	} else if err != nil {
+		return err
+	}
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	if err := p.expect(t_left_braket); err != nil {
+		return err
+	}
+
+	count := 0
+	for p.t.Type != t_eof && p.t.Type != t_right_braket {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_braket) {
+				break
+			}
+			if p.l.cfg.maxArray > 0 && count >= p.l.cfg.maxArray {
+				return p.l.limitError(ErrMaxArrayLength, p.l.cfg.maxArray, p.l.start)
+			}
+		}
+		if err := p.walkValue(h); err != nil {
+			return err
+		}
+		count++
+	}
+
+	if err := p.expect(t_right_braket); err != nil {
+		return err
+	}
+	return h.OnArrayEnd()
+}
+
+// skip consumes the current value without producing anything, the input is
+// checked just like when parsing
+func (p *parser) skip() error {
+	switch p.t.Type {
+	case t_left_curly, t_left_braket:
+	case t_string, t_number, t_true, t_false, t_null:
+		return p.advance()
+	default:
+		return p.unexpectedValue()
+	}
+
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+
+	object := p.t.Type == t_left_curly
+	closing := t_right_braket
+	limit, limitErr := p.l.cfg.maxArray, ErrMaxArrayLength
+	if object {
+		closing = t_right_curly
+		limit, limitErr = p.l.cfg.maxMembers, ErrMaxObjectMembers
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+
+	count := 0
+	for p.t.Type != t_eof && p.t.Type != closing {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(closing) {
+				break
+			}
+			if limit > 0 && count >= limit {
+				return p.l.limitError(limitErr, limit, p.l.start)
+			}
+		}
+		if object {
+			if err := p.skipKey(); err != nil {
+				return err
+			}
+			if err := p.expect(t_colon); err != nil {
+				return err
+			}
+		}
+		if err := p.skip(); err != nil {
+			return err
+		}
+		count++
+	}
+
+	return p.expect(closing)
+}
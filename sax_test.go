@@ -0,0 +1,161 @@
+package libjson
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records every event as a string
+type recorder struct {
+	events  []string
+	skipKey string
+	skipAll bool
+}
+
+func (r *recorder) OnObjectStart() error {
+	r.events = append(r.events, "{")
+	if r.skipAll && len(r.events) > 1 {
+		return ErrSkip
+	}
+	return nil
+}
+func (r *recorder) OnKey(key string) error {
+	r.events = append(r.events, "key:"+key)
+	if key == r.skipKey {
+		return ErrSkip
+	}
+	return nil
+}
+func (r *recorder) OnObjectEnd() error { r.events = append(r.events, "}"); return nil }
+func (r *recorder) OnArrayStart() error {
+	r.events = append(r.events, "[")
+	if r.skipAll && len(r.events) > 1 {
+		return ErrSkip
+	}
+	return nil
+}
+func (r *recorder) OnArrayEnd() error       { r.events = append(r.events, "]"); return nil }
+func (r *recorder) OnString(s string) error { r.events = append(r.events, "str:"+s); return nil }
+func (r *recorder) OnNumber(n Number) error {
+	r.events = append(r.events, "num:"+n.String())
+	return nil
+}
+func (r *recorder) OnBool(b bool) error { r.events = append(r.events, fmt.Sprint(b)); return nil }
+func (r *recorder) OnNull() error       { r.events = append(r.events, "null"); return nil }
+
+const saxInput = `{"a": [1, "two", true, false, null], "skip": {"x": [1, {"y": 2}]}, "b": {}}`
+
+func TestWalk(t *testing.T) {
+	r := &recorder{}
+	assert.NoError(t, Walk([]byte(saxInput), r))
+	assert.Equal(t, []string{
+		"{",
+		"key:a", "[", "num:1", "str:two", "true", "false", "null", "]",
+		"key:skip", "{", "key:x", "[", "num:1", "{", "key:y", "num:2", "}", "]", "}",
+		"key:b", "{", "}",
+		"}",
+	}, r.events)
+
+	streamed := &recorder{}
+	assert.NoError(t, WalkReader(iotest.OneByteReader(strings.NewReader(saxInput)), streamed))
+	assert.Equal(t, r.events, streamed.events)
+}
+
+func TestWalkSkip(t *testing.T) {
+	r := &recorder{skipKey: "skip"}
+	assert.NoError(t, Walk([]byte(saxInput), r))
+	assert.Equal(t, []string{
+		"{",
+		"key:a", "[", "num:1", "str:two", "true", "false", "null", "]",
+		"key:skip",
+		"key:b", "{", "}",
+		"}",
+	}, r.events)
+
+	r = &recorder{skipAll: true}
+	assert.NoError(t, Walk([]byte(saxInput), r))
+	assert.Equal(t, []string{"{", "key:a", "[", "key:skip", "{", "key:b", "{", "}"}, r.events)
+
+	// skipped subtrees are still checked
+	r = &recorder{skipKey: "skip"}
+	assert.Error(t, Walk([]byte(`{"skip": [1,,2]}`), r))
+	assert.ErrorIs(t, Walk([]byte(`{"skip": [[[]]]}`), r, WithMaxDepth(2)), ErrMaxDepth)
+}
+
+// counter aggregates numbers and aborts after limit numbers
+type counter struct {
+	NopHandler
+	sum   float64
+	seen  int
+	limit int
+}
+
+var errEnough = errors.New("enough")
+
+func (c *counter) OnNumber(n Number) error {
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	c.sum += f
+	c.seen++
+	if c.limit > 0 && c.seen == c.limit {
+		return errEnough
+	}
+	return nil
+}
+
+func TestWalkAbort(t *testing.T) {
+	c := &counter{}
+	assert.NoError(t, Walk([]byte(`[1, 2.5, {"a": [3]}]`), c))
+	assert.Equal(t, 6.5, c.sum)
+
+	c = &counter{limit: 2}
+	assert.ErrorIs(t, Walk([]byte(`[1, 2.5, {"a": [3]}, tru]`), c), errEnough)
+	assert.Equal(t, 3.5, c.sum)
+}
+
+func TestWalkFail(t *testing.T) {
+	for _, in := range streamInputs {
+		t.Run(in, func(t *testing.T) {
+			_, wantErr := New([]byte(in))
+			err := Walk([]byte(in), NopHandler{})
+			assert.Equal(t, wantErr, err)
+		})
+	}
+}
+
+func TestWalkMatchesNew(t *testing.T) {
+	inputs := []string{
+		`{"a": 1, "a": 2}`,
+		`[{"a": {"b": 1, "b": 2}}]`,
+		`[1e400]`,
+		`{"a": [1, 2, -1e999]}`,
+		`{"a": 1, "b": 2}`,
+	}
+	for _, opts := range [][]Option{
+		nil,
+		{WithDuplicateKeys(DuplicateError)},
+		{WithNumberMode(NumberInteger)},
+		{WithDuplicateKeys(DuplicateError), WithJSON5()},
+	} {
+		for _, in := range inputs {
+			_, expected := New([]byte(in), opts...)
+			assert.Equal(t, expected, Walk([]byte(in), NopHandler{}, opts...), in)
+			assert.Equal(t, expected, WalkReader(strings.NewReader(in), NopHandler{}, opts...), in)
+			// skipped values are checked too
+			assert.Equal(t, expected, Walk([]byte(in), skipAll{}, opts...), in)
+		}
+	}
+}
+
+// skipAll skips every object and array
+type skipAll struct{ NopHandler }
+
+func (skipAll) OnObjectStart() error { return ErrSkip }
+func (skipAll) OnArrayStart() error  { return ErrSkip }
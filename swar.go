@@ -0,0 +1,111 @@
+package libjson
+
+import (
+	"encoding/binary"
+	"iter"
+	"math/bits"
+)
+
+// SWAR (SIMD within a register) helpers, scanning 8 bytes at a time by
+// treating them as a single uint64. All masks have the most significant bit
+// of every matching byte set
+
+const (
+	lsb   = 0x0101010101010101
+	msb   = 0x8080808080808080
+	mask7 = 0x7f7f7f7f7f7f7f7f
+)
+
+// zeros returns a mask of the zero bytes of x, it is exact for every byte,
+// unlike the cheaper (x - lsb) &^ x & msb, which is only exact for the lowest
+// zero byte
+func zeros(x uint64) uint64 {
+	return ^((x&mask7 + mask7) | x | mask7)
+}
+
+// equal returns a mask of the bytes of x equal to b
+func equal(x uint64, b byte) uint64 {
+	return zeros(x ^ (lsb * uint64(b)))
+}
+
+// less returns a mask of the bytes of x less than n, n has to be at most 128
+func less(x uint64, n byte) uint64 {
+	return (x - lsb*uint64(n)) &^ x & msb
+}
+
+// first returns the index of the lowest byte set in mask
+func first(mask uint64) int {
+	return bits.TrailingZeros64(mask) / 8
+}
+
+// stringEnd returns the index of the first '"', '\' or control character in
+// data starting at i, or len(data) if there is none
+func stringEnd(data []byte, i int) int {
+	for ; i+8 <= len(data); i += 8 {
+		x := binary.LittleEndian.Uint64(data[i:])
+		if mask := equal(x, '"') | equal(x, '\\') | less(x, 0x20); mask != 0 {
+			return i + first(mask)
+		}
+	}
+	for ; i < len(data); i++ {
+		if cc := data[i]; cc == '"' || cc == '\\' || cc < 0x20 {
+			return i
+		}
+	}
+	return i
+}
+
+func isSpace(cc byte) bool {
+	return cc == ' ' || cc == '\n' || cc == '\t' || cc == '\r'
+}
+
+// skipSpace returns the index of the first non whitespace byte in data
+// starting at i, or len(data) if there is none. Single spaces between tokens
+// are the common case, so the first byte is checked on its own
+func skipSpace(data []byte, i int) int {
+	if i < len(data) && !isSpace(data[i]) {
+		return i
+	}
+	for ; i+8 <= len(data); i += 8 {
+		x := binary.LittleEndian.Uint64(data[i:])
+		space := equal(x, ' ') | equal(x, '\n') | equal(x, '\t') | equal(x, '\r')
+		if mask := ^space & msb; mask != 0 {
+			return i + first(mask)
+		}
+	}
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// delimiters are the bytes ending a number or literal
+var delimiters = [256]bool{' ': true, '\n': true, '\t': true, '\r': true, ',': true, ':': true, '{': true, '}': true, '[': true, ']': true, '"': true}
+
+// tokenStarts yields the offset of the first byte of every token in data.
+// Invalid input is not rejected, it is treated as if it was a single token
+// and the lexer reports the error once it reaches the token
+func tokenStarts(data []byte) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := skipSpace(data, 0); i < len(data); i = skipSpace(data, i) {
+			if !yield(i) {
+				return
+			}
+			switch data[i] {
+			case '{', '}', '[', ']', ',', ':':
+				i++
+			case '"':
+				for i = stringEnd(data, i+1); i < len(data) && data[i] != '"'; i = stringEnd(data, i) {
+					if data[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				i++
+			default:
+				for i++; i < len(data) && !delimiters[data[i]]; i++ {
+				}
+			}
+		}
+	}
+}
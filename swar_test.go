@@ -0,0 +1,43 @@
+package libjson
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSWAR(t *testing.T) {
+	alphabet := []byte(" \t\n\r\"\\\x00\x1f\x7f\x80\xffa{")
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 10000; n++ {
+		data := make([]byte, r.Intn(40))
+		for i := range data {
+			data[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		from := r.Intn(len(data) + 1)
+
+		end := from
+		for end < len(data) && data[end] != '"' && data[end] != '\\' && data[end] >= 0x20 {
+			end++
+		}
+		assert.Equal(t, end, stringEnd(data, from), "stringEnd(%q, %d)", data, from)
+
+		end = from
+		for end < len(data) && isSpace(data[end]) {
+			end++
+		}
+		assert.Equal(t, end, skipSpace(data, from), "skipSpace(%q, %d)", data, from)
+	}
+}
+
+func TestTokenStarts(t *testing.T) {
+	input := `{"a\"b" : [1, -2.5e3,true,"\\"],` + "\n\t" + `"c":null , "long string without escapes":false}`
+	var offsets []int
+	for tok, err := range NewTokenizer([]byte(input)).All() {
+		assert.NoError(t, err)
+		offsets = append(offsets, tok.Offset)
+	}
+	assert.Equal(t, offsets, slices.Collect(tokenStarts([]byte(input))))
+}
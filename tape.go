@@ -0,0 +1,602 @@
+package libjson
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// WithTape makes New and NewReader store the document as a flat tape of
+// tagged 64 bit entries and a buffer holding all strings, instead of a tree
+// of maps and slices. Get, MarshalJSON, Members and Elements work on the
+// tape directly, maps and slices are only built for the values they return.
+// Set converts the whole document into a tree first. WithTape is ignored
+// for DuplicateCollect
+func WithTape() Option {
+	return func(c *config) {
+		c.tape = true
+	}
+}
+
+// tape entries hold a tag in their most significant byte and a payload in
+// the remaining 56 bits:
+//
+//	'n', 't', 'f'   null, true and false, no payload
+//	's'             string, offset of its length prefixed bytes in strs
+//	'N'             number kept as text, like 's'
+//	'd', 'l', 'u'   float64, int64 and uint64, the value is stored in the
+//	                next entry
+//	'{', '['        start of an object or array, the index of the entry after
+//	                the container in the lower 32 bits and the amount of
+//	                members or elements in the upper 24 bits, saturated at
+//	                maxCount
+//	'}', ']'        end of an object or array, the index of its start
+//
+// objects hold their members as 's' entries for keys, each followed by the
+// entries of the value
+type tape struct {
+	entries []uint64
+	strs    []byte
+	cfg     config
+}
+
+const (
+	payloadMask = 1<<56 - 1
+	maxCount    = 1<<24 - 1
+)
+
+func (t *tape) push(tag byte, payload uint64) {
+	t.entries = append(t.entries, uint64(tag)<<56|payload)
+}
+
+func (t *tape) pushString(tag byte, s string) {
+	t.push(tag, uint64(len(t.strs)))
+	t.strs = binary.LittleEndian.AppendUint32(t.strs, uint32(len(s)))
+	t.strs = append(t.strs, s...)
+}
+
+func (t *tape) tag(i int) byte {
+	return byte(t.entries[i] >> 56)
+}
+
+func (t *tape) payload(i int) uint64 {
+	return t.entries[i] & payloadMask
+}
+
+// str returns the string or number text at i, it aliases t.strs, which is
+// never modified after parsing
+func (t *tape) str(i int) string {
+	off := t.payload(i)
+	n := uint64(binary.LittleEndian.Uint32(t.strs[off:]))
+	b := t.strs[off+4 : off+4+n]
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// count returns the amount of members or elements of the container at i,
+// members with duplicate keys are counted as well. Counts saturated at
+// maxCount are counted by walking the container
+func (t *tape) count(i int) int {
+	n := int(t.payload(i) >> 32)
+	if n < maxCount {
+		return n
+	}
+	n = 0
+	if t.tag(i) == '{' {
+		for j := i + 1; t.tag(j) != '}'; j = t.next(j + 1) {
+			n++
+		}
+	} else {
+		for j := i + 1; t.tag(j) != ']'; j = t.next(j) {
+			n++
+		}
+	}
+	return n
+}
+
+// next returns the index of the value following the value at i
+func (t *tape) next(i int) int {
+	switch t.tag(i) {
+	case '{', '[':
+		return int(t.payload(i) & math.MaxUint32)
+	case 'd', 'l', 'u':
+		return i + 2
+	}
+	return i + 1
+}
+
+// close patches the start of the container at start once its end at
+// len(t.entries) is known
+func (t *tape) close(start int, tag byte, count int) {
+	t.push(tag, uint64(start))
+	t.entries[start] |= uint64(min(count, maxCount))<<32 | uint64(len(t.entries))
+}
+
+func (p *parser) tapeValue(t *tape) error {
+	switch p.t.Type {
+	case t_left_curly:
+		return p.tapeObject(t)
+	case t_left_braket:
+		return p.tapeArray(t)
+	case t_string:
+		t.pushString('s', *(*string)(unsafe.Pointer(&p.t.Val)))
+	case t_number:
+		if p.l.cfg.numbers == NumberFloat64 && !p.l.cfg.json5 {
+			// the common case, without boxing the float into an interface
+			f, err := p.float(*(*string)(unsafe.Pointer(&p.t.Val)))
+			if err != nil {
+				return err
+			}
+			t.push('d', 0)
+			t.entries = append(t.entries, math.Float64bits(f))
+			break
+		}
+		number, err := p.number()
+		if err != nil {
+			return err
+		}
+		switch n := number.(type) {
+		case float64:
+			t.push('d', 0)
+			t.entries = append(t.entries, math.Float64bits(n))
+		case int64:
+			t.push('l', 0)
+			t.entries = append(t.entries, uint64(n))
+		case uint64:
+			t.push('u', 0)
+			t.entries = append(t.entries, n)
+		default:
+			// Number and math/big values are converted again on access
+			t.pushString('N', *(*string)(unsafe.Pointer(&p.t.Val)))
+		}
+	case t_true:
+		t.push('t', 0)
+	case t_false:
+		t.push('f', 0)
+	case t_null:
+		t.push('n', 0)
+	default:
+		return p.unexpectedValue()
+	}
+	return p.advance()
+}
+
+func (p *parser) tapeObject(t *tape) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+	if err := p.expect(t_left_curly); err != nil {
+		return err
+	}
+
+	start := len(t.entries)
+	t.push('{', 0)
+	count := 0
+	var seen map[string]struct{} // only used for DuplicateError
+	for p.t.Type != t_eof && p.t.Type != t_right_curly {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_curly) {
+				break
+			}
+			if p.l.cfg.maxMembers > 0 && count >= p.l.cfg.maxMembers {
+				return p.l.limitError(ErrMaxObjectMembers, p.l.cfg.maxMembers, p.l.start)
+			}
+		}
+
+		keyStart := p.keyOffset()
+		key, err := p.key()
+		if err != nil {
+			return err
+		}
+		if p.l.cfg.duplicates == DuplicateError {
+			if seen == nil {
+				seen = make(map[string]struct{}, 8)
+			}
+			if _, ok := seen[key]; ok {
+				return p.l.errorf(keyStart-p.l.offset, "Duplicate key %q in object", key)
+			}
+			seen[key] = struct{}{}
+		}
+		t.pushString('s', key)
+		if err := p.expect(t_colon); err != nil {
+			return err
+		}
+		if err := p.tapeValue(t); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := p.expect(t_right_curly); err != nil {
+		return err
+	}
+	t.close(start, '}', count)
+	return nil
+}
+
+func (p *parser) tapeArray(t *tape) error {
+	if err := p.enter(); err != nil {
+		return err
+	}
+	defer p.leave()
+	if err := p.expect(t_left_braket); err != nil {
+		return err
+	}
+
+	start := len(t.entries)
+	t.push('[', 0)
+	count := 0
+	for p.t.Type != t_eof && p.t.Type != t_right_braket {
+		if count > 0 {
+			if err := p.expect(t_comma); err != nil {
+				return err
+			}
+			if p.trailingComma(t_right_braket) {
+				break
+			}
+			if p.l.cfg.maxArray > 0 && count >= p.l.cfg.maxArray {
+				return p.l.limitError(ErrMaxArrayLength, p.l.cfg.maxArray, p.l.start)
+			}
+		}
+		if err := p.tapeValue(t); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := p.expect(t_right_braket); err != nil {
+		return err
+	}
+	t.close(start, ']', count)
+	return nil
+}
+
+// parseTape parses the whole input of p into a tape
+func (p *parser) parseTape() (*tape, error) {
+	t := &tape{cfg: p.l.cfg}
+	if p.l.r == nil {
+		// about one entry per 8 bytes of input for typical documents
+		t.entries = make([]uint64, 0, len(p.l.data)/8)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if err := p.tapeValue(t); err != nil {
+		return nil, err
+	}
+	if p.t.Type != t_eof {
+		return nil, p.l.errorf(p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
+	}
+	return t, nil
+}
+
+// tapeDocument parses the input of p into a JSON backed by a tape
+func (p *parser) tapeDocument() (*JSON, error) {
+	t, err := p.parseTape()
+	if err != nil {
+		return nil, err
+	}
+	j := &JSON{tape: t, arena: p.arena}
+	p.arena = nil
+	return j, nil
+}
+
+// tapeMember is an object member, both fields are tape indexes
+type tapeMember struct {
+	key, value int
+}
+
+// members returns the members of the object at i in input order. Duplicate
+// keys are kept at the position of their first occurrence, with the value
+// selected by the DuplicateKeyPolicy
+func (t *tape) members(i int) []tapeMember {
+	ms := make([]tapeMember, 0, t.count(i))
+	var index map[string]int // position in ms, only built for large objects
+	for j := i + 1; t.tag(j) != '}'; j = t.next(j + 1) {
+		key := t.str(j)
+		pos := -1
+		if index == nil && len(ms) < indexThreshold {
+			for x := range ms {
+				if t.str(ms[x].key) == key {
+					pos = x
+					break
+				}
+			}
+		} else {
+			if index == nil {
+				index = make(map[string]int, t.count(i))
+				for x, m := range ms {
+					index[t.str(m.key)] = x
+				}
+			}
+			if x, ok := index[key]; ok {
+				pos = x
+			}
+		}
+
+		if pos == -1 {
+			if index != nil {
+				index[key] = len(ms)
+			}
+			ms = append(ms, tapeMember{j, j + 1})
+		} else if t.cfg.duplicates != DuplicateFirstWins {
+			ms[pos].value = j + 1
+		}
+	}
+	return ms
+}
+
+// member returns the index of the value of key in the object at i, or -1
+func (t *tape) member(i int, key string) int {
+	found := -1
+	for j := i + 1; t.tag(j) != '}'; j = t.next(j + 1) {
+		if t.str(j) == key {
+			found = j + 1
+			if t.cfg.duplicates == DuplicateFirstWins {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// element returns the index of the kth element of the array at i
+func (t *tape) element(i int, k int) int {
+	j := i + 1
+	for ; k > 0; k-- {
+		j = t.next(j)
+	}
+	return j
+}
+
+// find returns the index of the value at keys, or -1 for null values not
+// present in the tape, such as missing members. Errors match indexByKey
+func (t *tape) find(keys []any) (int, error) {
+	i := 0
+	for _, key := range keys {
+		tag := byte('n')
+		if i != -1 {
+			tag = t.tag(i)
+		}
+		switch tag {
+		case 'n':
+			return 0, errors.New("Can not index into null")
+		case 's':
+			return 0, errors.New("Can not index into string")
+		case 'N', 'd', 'l', 'u':
+			return 0, errors.New("Can not index into number")
+		case 't', 'f':
+			return 0, fmt.Errorf("Unsupported %T, can not index", true)
+		case '[':
+			n := t.count(i)
+			if n == 0 {
+				i = -1
+			} else if k, ok := key.(int); !ok {
+				v := t.value(i)
+				return 0, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, v, v)
+			} else if k >= n {
+				return 0, fmt.Errorf("Index %d out of range for array of length %d", k, n)
+			} else {
+				i = t.element(i, k)
+			}
+		case '{':
+			if t.count(i) == 0 && !t.cfg.ordered {
+				i = -1
+			} else if k, ok := key.(string); !ok {
+				v := t.value(i)
+				return 0, fmt.Errorf("Can not use %T::%v to index into %T::%v", key, key, v, v)
+			} else {
+				i = t.member(i, k)
+			}
+		}
+	}
+	return i, nil
+}
+
+// value converts the value at i into the types the parser produces, -1 is
+// null
+func (t *tape) value(i int) any {
+	if i == -1 {
+		return nil
+	}
+	switch t.tag(i) {
+	case 's':
+		return t.str(i)
+	case 'd':
+		return math.Float64frombits(t.entries[i+1])
+	case 'l':
+		return int64(t.entries[i+1])
+	case 'u':
+		return t.entries[i+1]
+	case 'N':
+		return t.number(i)
+	case 't':
+		return true
+	case 'f':
+		return false
+	case '[':
+		a := make([]any, 0, t.count(i))
+		for j := i + 1; t.tag(j) != ']'; j = t.next(j) {
+			a = append(a, t.value(j))
+		}
+		return a
+	case '{':
+		ms := t.members(i)
+		if t.cfg.ordered {
+			o := NewObject(len(ms))
+			for _, m := range ms {
+				o.Set(t.str(m.key), t.value(m.value))
+			}
+			return o
+		}
+		obj := make(map[string]any, len(ms))
+		for _, m := range ms {
+			obj[t.str(m.key)] = t.value(m.value)
+		}
+		return obj
+	}
+	return nil
+}
+
+// number converts the number text at i according to t.cfg.numbers, it was
+// already converted once while parsing, so it can not fail
+func (t *tape) number(i int) any {
+	s := t.str(i)
+	if t.cfg.numbers == NumberText {
+		return Number(s)
+	}
+	p := parser{l: lexer{data: unsafe.Slice(unsafe.StringData(s), len(s)), cfg: t.cfg}}
+	if err := p.advance(); err != nil {
+		return nil
+	}
+	n, _ := p.number()
+	return n
+}
+
+// append serializes the value at i like appendValue serializes the tree
+func (t *tape) append(buf []byte, i int) ([]byte, error) {
+	var err error
+	switch t.tag(i) {
+	case 'n':
+		return append(buf, "null"...), nil
+	case 't':
+		return append(buf, "true"...), nil
+	case 'f':
+		return append(buf, "false"...), nil
+	case 's':
+		return appendString(buf, t.str(i)), nil
+	case 'd':
+		return appendFloat(buf, math.Float64frombits(t.entries[i+1]))
+	case 'l':
+		return strconv.AppendInt(buf, int64(t.entries[i+1]), 10), nil
+	case 'u':
+		return strconv.AppendUint(buf, t.entries[i+1], 10), nil
+	case 'N':
+		return appendValue(buf, t.number(i))
+	case '[':
+		buf = append(buf, '[')
+		for j := i + 1; t.tag(j) != ']'; j = t.next(j) {
+			if j > i+1 {
+				buf = append(buf, ',')
+			}
+			if buf, err = t.append(buf, j); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case '{':
+		ms := t.members(i)
+		if !t.cfg.ordered {
+			// sorted for deterministic output, just like maps
+			slices.SortFunc(ms, func(a, b tapeMember) int {
+				return strings.Compare(t.str(a.key), t.str(b.key))
+			})
+		}
+		buf = append(buf, '{')
+		for x, m := range ms {
+			if x > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendString(buf, t.str(m.key))
+			buf = append(buf, ':')
+			if buf, err = t.append(buf, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	}
+	return nil, fmt.Errorf("Invalid tape entry %q", t.tag(i))
+}
+
+// Members iterates over the members of the object at path. Documents parsed
+// WithTape or WithOrderedObjects yield them in input order, map[string]any
+// objects in no particular order
+func (j *JSON) Members(path string) (iter.Seq2[string, any], error) {
+	if j.tape != nil {
+		i, err := j.tape.lookup(path)
+		if err != nil {
+			return nil, err
+		}
+		if i == -1 || j.tape.tag(i) != '{' {
+			return nil, fmt.Errorf("Can not iterate over %T, expected object", j.tape.value(i))
+		}
+		return func(yield func(string, any) bool) {
+			for _, m := range j.tape.members(i) {
+				if !yield(j.tape.str(m.key), j.tape.value(m.value)) {
+					return
+				}
+			}
+		}, nil
+	}
+
+	v, err := j.get(path)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		return func(yield func(string, any) bool) {
+			for k, val := range v {
+				if !yield(k, val) {
+					return
+				}
+			}
+		}, nil
+	case *Object:
+		return v.All(), nil
+	}
+	return nil, fmt.Errorf("Can not iterate over %T, expected object", v)
+}
+
+// Elements iterates over the elements of the array at path
+func (j *JSON) Elements(path string) (iter.Seq2[int, any], error) {
+	if j.tape != nil {
+		i, err := j.tape.lookup(path)
+		if err != nil {
+			return nil, err
+		}
+		if i == -1 || j.tape.tag(i) != '[' {
+			return nil, fmt.Errorf("Can not iterate over %T, expected array", j.tape.value(i))
+		}
+		return func(yield func(int, any) bool) {
+			k := 0
+			for e := i + 1; j.tape.tag(e) != ']'; e = j.tape.next(e) {
+				if !yield(k, j.tape.value(e)) {
+					return
+				}
+				k++
+			}
+		}, nil
+	}
+
+	v, err := j.get(path)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := v.([]any); ok {
+		return func(yield func(int, any) bool) {
+			for k, e := range a {
+				if !yield(k, e) {
+					return
+				}
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("Can not iterate over %T, expected array", v)
+}
+
+// lookup returns the tape index of the value at path, see find
+func (t *tape) lookup(path string) (int, error) {
+	keys, err := pathKeys(path)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", errors.ErrUnsupported, path)
+	}
+	return t.find(keys)
+}
@@ -0,0 +1,154 @@
+package libjson
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTape(t *testing.T) {
+	for _, opts := range [][]Option{
+		nil,
+		{WithOrderedObjects()},
+		{WithDuplicateKeys(DuplicateFirstWins)},
+		{WithOrderedObjects(), WithDuplicateKeys(DuplicateFirstWins)},
+		{WithNumberMode(NumberInteger)},
+		{WithNumberMode(NumberText)},
+		{WithNumberMode(NumberBig)},
+	} {
+		for i, tp := range checkBackend(t, "tape", WithTape(), opts...) {
+			assert.NotNil(t, tp.tape)
+			assert.Nil(t, tp.obj)
+			in := backendInputs[i]
+			r, err := NewReader(iotest.OneByteReader(strings.NewReader(in)), append(opts, WithTape())...)
+			assert.NoError(t, err, in)
+			assert.Equal(t, tp.tape, r.tape, in)
+		}
+	}
+}
+
+func TestTapeErrors(t *testing.T) {
+	for _, in := range []string{
+		``,
+		`[1, 2,`,
+		`{"a" 1}`,
+		`{"a": 1,}`,
+		`[1] 2`,
+		`{"a": 1, "a": 2}`,
+		`[[[[1]]]]`,
+		`[1, 2, 3, 4]`,
+	} {
+		opts := []Option{WithDuplicateKeys(DuplicateError), WithMaxDepth(3), WithMaxArrayLength(3)}
+		_, expected := New([]byte(in), opts...)
+		_, err := New([]byte(in), append(opts, WithTape())...)
+		assert.Error(t, err, in)
+		assert.Equal(t, expected, err, in)
+	}
+
+	// the window of a streaming lexer moves while the duplicate is read
+	in := "[" + strings.Repeat(" ", 2*snippetWidth) + "\n" + `{"a": 1, "a": 2}]`
+	opts := []Option{WithDuplicateKeys(DuplicateError), WithTape()}
+	_, expected := New([]byte(in), opts...)
+	_, err := NewReader(iotest.OneByteReader(strings.NewReader(in)), opts...)
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		serr.Snippet = expected.(*SyntaxError).Snippet
+		assert.Equal(t, expected, serr)
+	}
+}
+
+func TestTapeSet(t *testing.T) {
+	j, err := New([]byte(`{"a": [1, 2], "b": {"c": "d"}}`), WithTape())
+	assert.NoError(t, err)
+	assert.NoError(t, Set(j, ".a.1", "x"))
+	assert.Nil(t, j.tape)
+	v, err := Get[string](j, ".a.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", v)
+	out, err := j.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[1,"x"],"b":{"c":"d"}}`, string(out))
+}
+
+func TestTapeCollect(t *testing.T) {
+	j, err := New([]byte(`{"a": 1, "a": 2}`), WithTape(), WithDuplicateKeys(DuplicateCollect))
+	assert.NoError(t, err)
+	assert.Nil(t, j.tape)
+	assert.Equal(t, map[string]any{"a": []any{float64(1), float64(2)}}, j.obj)
+}
+
+func TestMembersElements(t *testing.T) {
+	in := `{"z": [1, "two", null], "a": {"y": 1, "x": 2, "y": 3}}`
+	for _, opts := range [][]Option{nil, {WithTape()}, {WithOrderedObjects()}} {
+		j, err := New([]byte(in), opts...)
+		assert.NoError(t, err)
+
+		members, err := j.Members(".a")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"y": float64(3), "x": float64(2)}, maps.Collect(members))
+
+		elements, err := j.Elements(".z")
+		assert.NoError(t, err)
+		var a []any
+		for i, e := range elements {
+			assert.Equal(t, len(a), i)
+			a = append(a, e)
+		}
+		assert.Equal(t, []any{float64(1), "two", nil}, a)
+
+		_, err = j.Members(".z")
+		assert.Error(t, err)
+		_, err = j.Elements(".a")
+		assert.Error(t, err)
+		_, err = j.Members(".missing")
+		assert.Error(t, err)
+		_, err = j.Elements(".z.5")
+		assert.Error(t, err)
+	}
+
+	// tape and ordered objects keep the input order
+	for _, opts := range [][]Option{{WithTape()}, {WithOrderedObjects()}} {
+		j, err := New([]byte(in), opts...)
+		assert.NoError(t, err)
+		members, err := j.Members(".")
+		assert.NoError(t, err)
+		var keys []string
+		for k := range members {
+			keys = append(keys, k)
+		}
+		assert.Equal(t, []string{"z", "a"}, keys)
+	}
+}
+
+func TestTapeLargeContainers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("parses more than 16M elements")
+	}
+	n := maxCount + 5
+	in := []byte("[" + strings.Repeat("null,", n) + "true]")
+	j, err := New(in, WithTape(), WithZeroCopy())
+	assert.NoError(t, err)
+	v, err := Get[bool](j, "."+strconv.Itoa(n))
+	assert.NoError(t, err)
+	assert.True(t, v)
+	_, err = j.get("." + strconv.Itoa(n+1))
+	assert.EqualError(t, err, fmt.Sprintf("Index %d out of range for array of length %d", n+1, n+1))
+	a, err := Get[[]any](j, ".")
+	assert.NoError(t, err)
+	assert.Len(t, a, n+1)
+	assert.Equal(t, n+1, cap(a))
+
+	// saturated counts of objects are walked as well
+	j, err = New([]byte(`{"a": 1, "b": [2, 3], "a": 4}`), WithTape())
+	assert.NoError(t, err)
+	j.tape.entries[0] |= maxCount << 32
+	assert.Equal(t, 3, j.tape.count(0))
+	obj, err := Get[map[string]any](j, ".")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": 4.0, "b": []any{2.0, 3.0}}, obj)
+}
@@ -0,0 +1,103 @@
+package libjson
+
+import (
+	"io"
+	"iter"
+)
+
+// TokenKind is the kind of a Token
+type TokenKind uint8
+
+const (
+	TokenString      = TokenKind(t_string)
+	TokenNumber      = TokenKind(t_number)
+	TokenTrue        = TokenKind(t_true)
+	TokenFalse       = TokenKind(t_false)
+	TokenNull        = TokenKind(t_null)
+	TokenObjectStart = TokenKind(t_left_curly)   // {
+	TokenObjectEnd   = TokenKind(t_right_curly)  // }
+	TokenArrayStart  = TokenKind(t_left_braket)  // [
+	TokenArrayEnd    = TokenKind(t_right_braket) // ]
+	TokenComma       = TokenKind(t_comma)
+	TokenColon       = TokenKind(t_colon)
+	TokenIdentifier  = TokenKind(t_ident) // unquoted object keys, only produced by WithJSON5
+)
+
+func (k TokenKind) String() string {
+	return tokennames[t_json(k)]
+}
+
+// Token is a single lexical element of the input. Raw and Value alias the
+// input passed to NewTokenizer, for NewTokenizerReader they are only valid
+// until the next call to Next
+type Token struct {
+	Kind   TokenKind
+	Raw    []byte // the token as it occurs in the input, including quotes
+	Value  []byte // strings without quotes and with escapes decoded, numbers as in Raw, nil for everything else
+	Offset int    // byte offset of the first byte of Raw in the input
+}
+
+// Tokenizer splits its input into tokens without checking the grammar, so
+// it is up to the caller to reject, for instance, `{]`. Lexical errors and
+// the options affecting the lexer, such as WithSurrogatePolicy and
+// WithMaxStringLength, are handled just like when parsing
+type Tokenizer struct {
+	l   lexer
+	err error
+}
+
+// NewTokenizer returns a Tokenizer over data
+func NewTokenizer(data []byte, opts ...Option) *Tokenizer {
+	return &Tokenizer{l: lexer{data: data, cfg: newConfig(opts)}}
+}
+
+// NewTokenizerReader returns a Tokenizer reading from r through a bounded
+// window, see NewReader
+func NewTokenizerReader(r io.Reader, opts ...Option) *Tokenizer {
+	return &Tokenizer{l: lexer{r: r, cfg: newConfig(opts)}}
+}
+
+// Next returns the next token, io.EOF once the input is exhausted. After an
+// error every further call returns the same error
+func (t *Tokenizer) Next() (Token, error) {
+	if t.err != nil {
+		return Token{}, t.err
+	}
+	tok, err := t.l.next()
+	if err == nil && tok.Type == t_eof {
+		err = io.EOF
+	}
+	if err != nil {
+		t.err = err
+		return Token{}, err
+	}
+	return Token{
+		Kind:   TokenKind(tok.Type),
+		Raw:    t.l.data[t.l.start:t.l.pos],
+		Value:  tok.Val,
+		Offset: t.l.offset + t.l.start,
+	}, nil
+}
+
+// All iterates over the remaining tokens, it stops after the first error,
+// io.EOF is not yielded:
+//
+//	for tok, err := range libjson.NewTokenizer(data).All() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(tok.Kind, string(tok.Raw))
+//	}
+func (t *Tokenizer) All() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for {
+			tok, err := t.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(tok, err) || err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package libjson
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizer(t *testing.T) {
+	input := `{"k\"ey": [1.5, true, false, null]}`
+	wanted := []Token{
+		{Kind: TokenObjectStart, Raw: []byte("{"), Offset: 0},
+		{Kind: TokenString, Raw: []byte(`"k\"ey"`), Value: []byte(`k"ey`), Offset: 1},
+		{Kind: TokenColon, Raw: []byte(":"), Offset: 8},
+		{Kind: TokenArrayStart, Raw: []byte("["), Offset: 10},
+		{Kind: TokenNumber, Raw: []byte("1.5"), Value: []byte("1.5"), Offset: 11},
+		{Kind: TokenComma, Raw: []byte(","), Offset: 14},
+		{Kind: TokenTrue, Raw: []byte("true"), Offset: 16},
+		{Kind: TokenComma, Raw: []byte(","), Offset: 20},
+		{Kind: TokenFalse, Raw: []byte("false"), Offset: 22},
+		{Kind: TokenComma, Raw: []byte(","), Offset: 27},
+		{Kind: TokenNull, Raw: []byte("null"), Offset: 29},
+		{Kind: TokenArrayEnd, Raw: []byte("]"), Offset: 33},
+		{Kind: TokenObjectEnd, Raw: []byte("}"), Offset: 34},
+	}
+
+	tokenizers := []*Tokenizer{
+		NewTokenizer([]byte(input)),
+		NewTokenizerReader(iotest.OneByteReader(strings.NewReader(input))),
+	}
+	for _, tz := range tokenizers {
+		toks := []Token{}
+		for tok, err := range tz.All() {
+			assert.NoError(t, err)
+			// copy, raw and value are only valid until the next token when streaming
+			tok.Raw = append([]byte{}, tok.Raw...)
+			if tok.Value != nil {
+				tok.Value = append([]byte{}, tok.Value...)
+			}
+			toks = append(toks, tok)
+		}
+		assert.Equal(t, wanted, toks)
+
+		_, err := tz.Next()
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+func TestTokenizerError(t *testing.T) {
+	tz := NewTokenizer([]byte(`[1, tru]`))
+	kinds := []TokenKind{}
+	var err error
+	for tok, e := range tz.All() {
+		if e != nil {
+			err = e
+			break
+		}
+		kinds = append(kinds, tok.Kind)
+	}
+	assert.Equal(t, []TokenKind{TokenArrayStart, TokenNumber, TokenComma}, kinds)
+	var serr *SyntaxError
+	if assert.ErrorAs(t, err, &serr) {
+		assert.Equal(t, 4, serr.Offset)
+	}
+	_, again := tz.Next()
+	assert.Equal(t, err, again)
+}
+
+func TestTokenKindString(t *testing.T) {
+	assert.Equal(t, "{", TokenObjectStart.String())
+	assert.Equal(t, "number", TokenNumber.String())
+}
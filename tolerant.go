@@ -0,0 +1,315 @@
+package libjson
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Repair marks a value NewTolerant invented, because the input was missing
+// it or it could not be parsed. Invented values are always null
+type Repair struct {
+	Path   string // path of the value in the tree, as accepted by Get
+	Offset int    // byte offset of the token found instead of the value
+}
+
+// NewTolerant parses data like New, but does not stop at syntax errors.
+// Instead it resynchronizes at the next ',', '}' or ']' and returns a best
+// effort tree, the values it had to invent and all errors, in input order.
+// A missing comma is assumed if the next token starts a value, missing
+// closing brackets are reported and assumed. Exceeding a limit stops the
+// parser, the tree then contains everything parsed up to that point
+func NewTolerant(data []byte, opts ...Option) (*JSON, []Repair, []error) {
+	cfg := newConfig(opts)
+	if cfg.maxBytes > 0 && len(data) > cfg.maxBytes {
+		return &JSON{}, nil, []error{&LimitError{Err: ErrMaxBytes, Limit: cfg.maxBytes, Offset: cfg.maxBytes}}
+	}
+	p := tolerant{parser: parser{l: lexer{data: cfg.own(data), cfg: cfg}}}
+	p.advance()
+	obj := p.value(".")
+	if p.t.Type != t_eof && p.t.Type != t_invalid {
+		p.fail(p.l.errorf(p.at, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type]))
+	}
+	return &JSON{obj: obj}, p.repairs, p.errs
+}
+
+// tolerant is a parser recording errors instead of returning them, it only
+// uses the parser methods that do not advance
+type tolerant struct {
+	parser
+	at      int // offset of the current token
+	errs    []error
+	repairs []Repair
+	fatal   bool // set after a limit was exceeded, p.t then stays t_eof
+
+	// currently open objects and arrays, to tell closing tokens ending an
+	// enclosing container from stray ones
+	objects, arrays int
+}
+
+// fail records err, errors at the offset of the previous syntax error are
+// dropped, they are mostly caused by the same problem
+func (p *tolerant) fail(err error) {
+	serr, ok := err.(*SyntaxError)
+	if !ok {
+		p.fatal = true
+		p.t = empty
+	} else if len(p.errs) > 0 {
+		if last, ok := p.errs[len(p.errs)-1].(*SyntaxError); ok && last.Offset == serr.Offset {
+			return
+		}
+	}
+	p.errs = append(p.errs, err)
+}
+
+func (p *tolerant) advance() {
+	if p.fatal {
+		return
+	}
+	t, err := p.l.next()
+	p.t = t
+	p.at = p.l.start
+	if err != nil {
+		p.fail(err)
+		if !p.fatal {
+			p.resync()
+			p.t = token{Type: t_invalid}
+		}
+	}
+}
+
+// resync moves the lexer behind the token it just rejected: behind the
+// closing quote of strings, for everything else to the next whitespace or
+// structural character
+func (p *tolerant) resync() {
+	l := &p.l
+	if l.start >= len(l.data) {
+		l.pos = len(l.data)
+		return
+	}
+	i := l.start + 1
+	if quote := l.data[l.start]; quote == '"' || (quote == '\'' && l.cfg.json5) {
+		for ; i < len(l.data) && l.data[i] != '\n'; i++ {
+			if l.data[i] == '\\' {
+				i++
+			} else if l.data[i] == quote {
+				i++
+				break
+			}
+		}
+	} else {
+		for i < len(l.data) && bytes.IndexByte([]byte(" \t\r\n,:{}[]\""), l.data[i]) == -1 {
+			i++
+		}
+	}
+	l.pos = min(i, len(l.data))
+}
+
+// missing records that null was invented for the value at path
+func (p *tolerant) missing(path string) any {
+	p.repairs = append(p.repairs, Repair{Path: path, Offset: p.at})
+	return nil
+}
+
+// closes reports whether t closes the current or an enclosing container
+func (p *tolerant) closes(t t_json) bool {
+	return (t == t_right_curly && p.objects > 0) || (t == t_right_braket && p.arrays > 0)
+}
+
+// startsValue reports whether t is the first token of a value, invalid
+// tokens and identifiers are most likely misspelled values
+func startsValue(t t_json) bool {
+	switch t {
+	case t_string, t_number, t_true, t_false, t_null, t_left_curly, t_left_braket, t_ident, t_invalid:
+		return true
+	}
+	return false
+}
+
+func childPath(path string, key string) string {
+	if path == "." {
+		return path + key
+	}
+	return path + "." + key
+}
+
+func (p *tolerant) value(path string) any {
+	var r any
+	switch p.t.Type {
+	case t_left_curly:
+		return p.object(path)
+	case t_left_braket:
+		return p.array(path)
+	case t_string:
+		r = p.str()
+	case t_number:
+		number, err := p.number()
+		if err != nil {
+			p.fail(err)
+			r = p.missing(path)
+		} else {
+			r = number
+		}
+	case t_true:
+		r = true
+	case t_false:
+		r = false
+	case t_null:
+		r = nil
+	case t_invalid:
+		r = p.missing(path)
+	case t_ident:
+		p.fail(p.unexpectedValue())
+		r = p.missing(path)
+	default:
+		// not consumed, the token is handled by the enclosing container
+		if !p.fatal {
+			p.fail(p.unexpectedValue())
+		}
+		return p.missing(path)
+	}
+	p.advance()
+	return r
+}
+
+func (p *tolerant) object(path string) any {
+	if err := p.enter(); err != nil {
+		p.fail(err)
+		return nil
+	}
+	defer p.leave()
+	p.advance()
+	p.objects++
+	defer func() { p.objects-- }()
+
+	m := p.newMembers()
+	count := 0
+	for !p.closes(p.t.Type) && p.t.Type != t_eof {
+		if p.l.cfg.maxMembers > 0 && count >= p.l.cfg.maxMembers {
+			p.fail(p.l.limitError(ErrMaxObjectMembers, p.l.cfg.maxMembers, p.at))
+			break
+		}
+
+		keyStart := p.at
+		if p.t.Type != t_string && !(p.l.cfg.json5 && identKey(p.t)) {
+			if p.t.Type != t_invalid {
+				p.fail(p.l.errorf(p.at, "Unexpected %q at this position, expected %q", tokennames[p.t.Type], tokennames[t_string]))
+			}
+			// the member is dropped, there is no key to store it under
+			p.skip()
+			p.comma(t_right_curly)
+			continue
+		}
+		key := p.str()
+		if err := p.duplicate(&m, key, keyStart); err != nil {
+			p.fail(err)
+		}
+		p.advance()
+
+		var val any
+		if p.t.Type == t_colon {
+			p.advance()
+			val = p.value(childPath(path, key))
+		} else {
+			p.fail(p.l.errorf(p.at, "Unexpected %q at this position, expected %q", tokennames[p.t.Type], tokennames[t_colon]))
+			if startsValue(p.t.Type) {
+				val = p.value(childPath(path, key))
+			} else {
+				val = p.missing(childPath(path, key))
+			}
+		}
+		count++
+		p.addMember(&m, key, val)
+		p.separator(t_right_curly)
+	}
+	p.close(t_right_curly)
+	return m.value()
+}
+
+func (p *tolerant) array(path string) any {
+	if err := p.enter(); err != nil {
+		p.fail(err)
+		return nil
+	}
+	defer p.leave()
+	p.advance()
+	p.arrays++
+	defer func() { p.arrays-- }()
+
+	a := make([]any, 0, 8)
+	for !p.closes(p.t.Type) && p.t.Type != t_eof {
+		if p.l.cfg.maxArray > 0 && len(a) >= p.l.cfg.maxArray {
+			p.fail(p.l.limitError(ErrMaxArrayLength, p.l.cfg.maxArray, p.at))
+			break
+		}
+		a = append(a, p.value(childPath(path, strconv.Itoa(len(a)))))
+		p.separator(t_right_braket)
+	}
+	p.close(t_right_braket)
+	return a
+}
+
+// separator consumes the comma following a member or element. A missing
+// comma is assumed if the current token starts a value, otherwise everything
+// up to the next comma or closing token is skipped
+func (p *tolerant) separator(closing t_json) {
+	switch {
+	case p.t.Type == t_comma:
+		p.comma(closing)
+	case p.closes(p.t.Type) || p.t.Type == t_eof:
+	default:
+		if p.t.Type != t_invalid {
+			p.fail(p.l.errorf(p.at, "Unexpected %q at this position, expected %q or %q", tokennames[p.t.Type], tokennames[t_comma], tokennames[closing]))
+		}
+		if !startsValue(p.t.Type) {
+			p.skip()
+			p.comma(closing)
+		}
+	}
+}
+
+// comma consumes a comma, if it is the current token, trailing commas are
+// only allowed in JSON5 mode
+func (p *tolerant) comma(closing t_json) {
+	if p.t.Type != t_comma {
+		return
+	}
+	p.advance()
+	if p.t.Type == closing && !p.l.cfg.json5 {
+		p.fail(p.unexpectedValue())
+	}
+}
+
+// skip consumes tokens until a comma of the current container or a token
+// closing it or an enclosing one, nested containers and stray closing tokens
+// are skipped
+func (p *tolerant) skip() {
+	depth := 0
+	for p.t.Type != t_eof {
+		switch p.t.Type {
+		case t_left_curly, t_left_braket:
+			depth++
+		case t_right_curly, t_right_braket:
+			if depth > 0 {
+				depth--
+			} else if p.closes(p.t.Type) {
+				return
+			}
+		case t_comma:
+			if depth == 0 {
+				return
+			}
+		}
+		p.advance()
+	}
+}
+
+// close consumes the closing token of a container, a missing closing token
+// is reported and assumed, the current token is then left to the enclosing
+// container
+func (p *tolerant) close(closing t_json) {
+	if p.t.Type == closing {
+		p.advance()
+	} else if !p.fatal {
+		p.fail(p.l.errorf(p.at, "Unexpected %q at this position, expected %q", tokennames[p.t.Type], tokennames[closing]))
+	}
+}
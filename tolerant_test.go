@@ -0,0 +1,81 @@
+package libjson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTolerantValid(t *testing.T) {
+	for _, in := range []string{`{"a":[1,true,null,"x"],"b":{}}`, "[]", `"str"`, " 1.5 "} {
+		t.Run(in, func(t *testing.T) {
+			obj, repairs, errs := NewTolerant([]byte(in))
+			assert.Empty(t, errs)
+			assert.Empty(t, repairs)
+			expected, err := New([]byte(in))
+			assert.NoError(t, err)
+			assert.Equal(t, expected.obj, obj.obj)
+		})
+	}
+}
+
+func TestTolerant(t *testing.T) {
+	tests := []struct {
+		inp      string
+		expected any
+		repairs  []Repair
+		offsets  []int
+	}{
+		{"", nil, []Repair{{".", 0}}, []int{0}},
+		{"tru", nil, []Repair{{".", 0}}, []int{0}},
+		{"[1 2]", []any{1.0, 2.0}, nil, []int{3}},
+		{"[1,,2]", []any{1.0, nil, 2.0}, []Repair{{".1", 3}}, []int{3}},
+		{"[1,]", []any{1.0}, nil, []int{3}},
+		{"[01, tru, 3]", []any{nil, nil, 3.0}, []Repair{{".0", 1}, {".1", 5}}, []int{2, 5}},
+		{"[1, 2", []any{1.0, 2.0}, nil, []int{5}},
+		{`{"a": [1, "b": 2}`, map[string]any{"a": []any{1.0, "b"}}, nil, []int{13, 16}},
+		{`{"a" 1, "b": }`, map[string]any{"a": 1.0, "b": nil}, []Repair{{".b", 13}}, []int{5, 13}},
+		{`{"a": 1 "b": 2}`, map[string]any{"a": 1.0, "b": 2.0}, nil, []int{8}},
+		{`{a: 1, "b": "\q", "c": [}], "d": 4}`, map[string]any{"b": nil, "c": []any{}}, []Repair{{".b", 12}}, []int{1, 13, 24, 25}},
+		{`{"x": {"y": [1, {"z": nul}]}}`, map[string]any{"x": map[string]any{"y": []any{1.0, map[string]any{"z": nil}}}}, []Repair{{".x.y.1.z", 22}}, []int{22}},
+		{"[1] 2", []any{1.0}, nil, []int{4}},
+	}
+	for _, test := range tests {
+		t.Run(test.inp, func(t *testing.T) {
+			obj, repairs, errs := NewTolerant([]byte(test.inp))
+			assert.Equal(t, test.expected, obj.obj)
+			assert.Equal(t, test.repairs, repairs)
+			offsets := []int{}
+			for _, err := range errs {
+				var serr *SyntaxError
+				if assert.True(t, errors.As(err, &serr), err) {
+					offsets = append(offsets, serr.Offset)
+				}
+			}
+			assert.Equal(t, test.offsets, offsets)
+		})
+	}
+}
+
+func TestTolerantOptions(t *testing.T) {
+	obj, repairs, errs := NewTolerant([]byte("{a: 1, b: [NaN,],}"), WithJSON5())
+	assert.Empty(t, errs)
+	assert.Empty(t, repairs)
+	assert.Len(t, obj.obj, 2)
+
+	obj, _, errs = NewTolerant([]byte(`{"a": 1, "a": 2, "b": [}`), WithDuplicateKeys(DuplicateError))
+	assert.Equal(t, map[string]any{"a": 1.0, "b": []any{}}, obj.obj)
+	assert.Len(t, errs, 2)
+
+	obj, _, errs = NewTolerant([]byte("[[1], [[2]], 3 4]"), WithMaxDepth(2))
+	assert.Equal(t, []any{[]any{1.0}, []any{nil}}, obj.obj)
+	if assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], ErrMaxDepth)
+	}
+
+	_, _, errs = NewTolerant([]byte("[1,2,3]"), WithMaxBytes(2))
+	if assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], ErrMaxBytes)
+	}
+}
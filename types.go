@@ -17,7 +17,9 @@ const (
 	t_right_braket               // ]
 	t_comma                      // ,
 	t_colon                      // :
+	t_ident                      // unquoted object keys, only produced in JSON5 mode
 	t_eof                        // for any non structure characters outside of strings and numbers
+	t_invalid                    // a token the lexer rejected, only produced by the tolerant parser
 )
 
 var tokennames = map[t_json]string{
@@ -32,7 +34,9 @@ var tokennames = map[t_json]string{
 	t_right_braket: "]",
 	t_comma:        ",",
 	t_colon:        ":",
+	t_ident:        "identifier",
 	t_eof:          "EOF",
+	t_invalid:      "invalid token",
 }
 
 type token struct {
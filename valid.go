@@ -0,0 +1,60 @@
+package libjson
+
+import (
+	"io"
+	"sync"
+)
+
+// validators keep their buffers between calls to Valid and ValidReader
+var validators = sync.Pool{New: func() any { return new(parser) }}
+
+// Valid checks that data holds a single JSON value, returning the error New
+// would return for it with the same options. No values are built and nothing
+// is allocated for valid input, unless DuplicateError has to remember keys or
+// JSON5 is enabled
+func Valid(data []byte, opts ...Option) error {
+	p := validators.Get().(*parser)
+	defer validators.Put(p)
+	return p.valid(lexer{data: data}, opts)
+}
+
+// ValidReader checks the JSON value read from r like Valid, the input is read
+// through the same bounded window NewReader uses
+func ValidReader(r io.Reader, opts ...Option) error {
+	p := validators.Get().(*parser)
+	defer validators.Put(p)
+	return p.valid(lexer{r: r, data: p.window[:0]}, opts)
+}
+
+// valid validates the whole input of l, keeping no references to it
+func (p *parser) valid(l lexer, opts []Option) error {
+	p.l, p.t, p.depth = l, token{}, 0
+	// applied to p instead of using newConfig, which allocates
+	for _, o := range opts {
+		o(&p.l.cfg)
+	}
+	// keys are only kept to detect duplicates
+	p.l.reuse = p.l.cfg.duplicates != DuplicateError
+	p.l.scratch = p.scratch[:0]
+	defer func() {
+		p.scratch = p.l.scratch[:0]
+		if p.l.r != nil {
+			p.window = p.l.data[:0]
+		}
+		p.l, p.t = lexer{}, token{}
+	}()
+
+	if max := p.l.cfg.maxBytes; max > 0 && p.l.r == nil && len(p.l.data) > max {
+		return &LimitError{Err: ErrMaxBytes, Limit: max, Offset: max}
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if p.t.Type != t_eof {
+		return p.l.errorf(p.l.start, "Unexpected non-whitespace character(s) (%s) after JSON data", tokennames[p.t.Type])
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package libjson
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	inputs := append([]string{
+		``,
+		`   `,
+		`[1, 2,`,
+		`{"a" 1}`,
+		`{"a": 1,}`,
+		`[1] 2`,
+		`{"a": 1, "a": 2}`,
+		`[[[[1]]]]`,
+		`[1, 2, 3, 4]`,
+		`[1e400]`,
+		`{"a": "\x"}`,
+		`{"a\nä": ["😀", "\ud800"]}`,
+		`[tru]`,
+		`{1: 2}`,
+		`// comment
+		{a: 'b', c: [0x10, Infinity,],}`,
+	}, backendInputs...)
+	for _, opts := range [][]Option{
+		nil,
+		{WithDuplicateKeys(DuplicateError), WithMaxDepth(3), WithMaxArrayLength(3)},
+		{WithSurrogatePolicy(SurrogateReplace), WithNumberMode(NumberInteger)},
+		{WithJSON5()},
+		{WithMaxBytes(10)},
+	} {
+		for _, in := range inputs {
+			_, expected := New([]byte(in), opts...)
+			assert.Equal(t, expected, Valid([]byte(in), opts...), in)
+
+			// snippets depend on the window contents when the error is found
+			_, expected = NewReader(iotest.OneByteReader(strings.NewReader(in)), opts...)
+			err := ValidReader(iotest.OneByteReader(strings.NewReader(in)), opts...)
+			if e, ok := expected.(*SyntaxError); ok {
+				if assert.IsType(t, e, err, in) {
+					e.Snippet = err.(*SyntaxError).Snippet
+				}
+			}
+			assert.Equal(t, expected, err, in)
+		}
+	}
+}
+
+func TestValidAllocs(t *testing.T) {
+	if race {
+		t.Skip("validators are reallocated if the pool drops them")
+	}
+	in := []byte(`{"key": [1, -2.5e3, true, false, null, "escaped \"\nä\"", {"nested": {}}]}`)
+	assert.NoError(t, Valid(in))
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = Valid(in)
+	})
+	assert.Zero(t, allocs)
+
+	r := bytes.NewReader(nil)
+	opts := []Option{WithMaxDepth(8), WithMaxStringLength(64)}
+	allocs = testing.AllocsPerRun(100, func() {
+		r.Reset(in)
+		_ = ValidReader(r, opts...)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestDuplicateErrorOrder(t *testing.T) {
+	// duplicates are reported before errors in their value
+	for _, in := range []string{
+		`{"k":1,"k":[01]}`,
+		`{"k":1,"k" 2}`,
+		`{"k":1,"k":}`,
+		`[{"a":{"b":1,"b":"\x"}}]`,
+	} {
+		opts := []Option{WithDuplicateKeys(DuplicateError)}
+		_, expected := New([]byte(in), opts...)
+		assert.ErrorContains(t, expected, "Duplicate key", in)
+		assert.Equal(t, expected, Valid([]byte(in), opts...), in)
+		_, err := New([]byte(in), append(opts, WithTape())...)
+		assert.Equal(t, expected, err, in)
+		_, err = New([]byte(in), append(opts, WithLazy())...)
+		assert.Equal(t, expected, err, in)
+	}
+}